@@ -0,0 +1,240 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// V2SpecAnnotation keeps the v2 spec of an autoscaler served as v1 when v1 can't express
+// all of it, e.g. when it scales on metrics other than CPU. Converting the object back to
+// v2 restores the spec from it, so that writing a v1 object doesn't drop v2 fields.
+const V2SpecAnnotation = "bigtable.bigtable-autoscaler.com/v2-spec"
+
+var _ conversion.Convertible = &BigtableAutoscaler{}
+
+// ConvertTo converts this BigtableAutoscaler to the Hub version (v2).
+func (src *BigtableAutoscaler) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*bigtablev2.BigtableAutoscaler)
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+
+	if err := restoreFromAnnotation(&dst.ObjectMeta, V2SpecAnnotation, &dst.Spec); err != nil {
+		return fmt.Errorf("failed to restore v2 spec from annotation: %w", err)
+	}
+
+	src.Spec.convertTo(&dst.Spec)
+	src.Status.convertTo(&dst.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v2) to this version.
+func (dst *BigtableAutoscaler) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*bigtablev2.BigtableAutoscaler)
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	dst.Spec.convertFrom(&src.Spec)
+	dst.Status.convertFrom(&src.Status)
+
+	var expressedSpec bigtablev2.BigtableAutoscalerSpec
+	dst.Spec.convertTo(&expressedSpec)
+
+	if !equality.Semantic.DeepEqual(expressedSpec, src.Spec) {
+		if err := storeInAnnotation(&dst.ObjectMeta, V2SpecAnnotation, src.Spec); err != nil {
+			return fmt.Errorf("failed to store v2 spec in annotation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// storeInAnnotation stores value as JSON in the annotation.
+func storeInAnnotation(meta *metav1.ObjectMeta, annotation string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[annotation] = string(raw)
+
+	return nil
+}
+
+// restoreFromAnnotation decodes the JSON of the annotation into value, when it is set, and
+// removes the annotation.
+func restoreFromAnnotation(meta *metav1.ObjectMeta, annotation string, value interface{}) error {
+	raw, ok := meta.Annotations[annotation]
+	if !ok {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), value); err != nil {
+		return err
+	}
+
+	delete(meta.Annotations, annotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+
+	return nil
+}
+
+func (s *BigtableAutoscalerSpec) convertTo(dst *bigtablev2.BigtableAutoscalerSpec) {
+	dst.MinNodes = s.MinNodes
+	dst.MaxNodes = s.MaxNodes
+	dst.MaxScaleDownNodes = s.MaxScaleDownNodes
+	dst.BigtableClusterRef = bigtablev2.BigtableClusterRef(s.BigtableClusterRef)
+	dst.ServiceAccountSecretRef = (*bigtablev2.ServiceAccountSecretRef)(s.ServiceAccountSecretRef)
+
+	dst.Metrics = withUtilizationTarget(dst.Metrics, bigtablev2.CPUMetricSourceType, s.TargetCPUUtilization)
+	dst.Metrics = withUtilizationTarget(dst.Metrics, bigtablev2.HottestNodeCPUMetricSourceType, s.TargetHottestNodeCPUUtilization)
+}
+
+// withUtilizationTarget sets the target of the metric of the given type, adding the metric
+// when it is missing and removing it when target is nil.
+func withUtilizationTarget(metrics []bigtablev2.MetricSpec, metricType bigtablev2.MetricSourceType, target *int32) []bigtablev2.MetricSpec {
+	for i := range metrics {
+		if metrics[i].Type != metricType {
+			continue
+		}
+
+		if target == nil {
+			return append(metrics[:i:i], metrics[i+1:]...)
+		}
+
+		metrics[i].Target = bigtablev2.MetricTarget{
+			Type:               bigtablev2.UtilizationMetricType,
+			AverageUtilization: target,
+		}
+
+		return metrics
+	}
+
+	if target == nil {
+		return metrics
+	}
+
+	return append(metrics, bigtablev2.MetricSpec{
+		Type: metricType,
+		Target: bigtablev2.MetricTarget{
+			Type:               bigtablev2.UtilizationMetricType,
+			AverageUtilization: target,
+		},
+	})
+}
+
+// utilizationTarget returns the target of the metric of the given type, or nil when it is not set.
+func utilizationTarget(metrics []bigtablev2.MetricSpec, metricType bigtablev2.MetricSourceType) *int32 {
+	for _, metric := range metrics {
+		if metric.Type == metricType && metric.Target.Type == bigtablev2.UtilizationMetricType {
+			return metric.Target.AverageUtilization
+		}
+	}
+
+	return nil
+}
+
+// withCurrentUtilization sets the current value of the metric of the given type, adding the
+// metric when it is missing and removing it when value is nil.
+func withCurrentUtilization(metrics []bigtablev2.MetricStatus, metricType bigtablev2.MetricSourceType, value *int32) []bigtablev2.MetricStatus {
+	for i := range metrics {
+		if metrics[i].Type != metricType {
+			continue
+		}
+
+		if value == nil {
+			return append(metrics[:i:i], metrics[i+1:]...)
+		}
+
+		metrics[i].Current.AverageUtilization = value
+
+		return metrics
+	}
+
+	if value == nil {
+		return metrics
+	}
+
+	return append(metrics, bigtablev2.MetricStatus{
+		Type:    metricType,
+		Current: bigtablev2.MetricValueStatus{AverageUtilization: value},
+	})
+}
+
+// currentUtilization returns the current value of the metric of the given type, or nil when it was not read.
+func currentUtilization(metrics []bigtablev2.MetricStatus, metricType bigtablev2.MetricSourceType) *int32 {
+	for _, metric := range metrics {
+		if metric.Type == metricType {
+			return metric.Current.AverageUtilization
+		}
+	}
+
+	return nil
+}
+
+func (s *BigtableAutoscalerSpec) convertFrom(src *bigtablev2.BigtableAutoscalerSpec) {
+	s.MinNodes = src.MinNodes
+	s.MaxNodes = src.MaxNodes
+	s.MaxScaleDownNodes = src.MaxScaleDownNodes
+	s.BigtableClusterRef = BigtableClusterRef(src.BigtableClusterRef)
+	s.ServiceAccountSecretRef = (*ServiceAccountSecretRef)(src.ServiceAccountSecretRef)
+	s.TargetCPUUtilization = utilizationTarget(src.Metrics, bigtablev2.CPUMetricSourceType)
+	s.TargetHottestNodeCPUUtilization = utilizationTarget(src.Metrics, bigtablev2.HottestNodeCPUMetricSourceType)
+}
+
+func (s *BigtableAutoscalerStatus) convertTo(dst *bigtablev2.BigtableAutoscalerStatus) {
+	dst.LastScaleTime = s.LastScaleTime
+	dst.LastFetchTime = s.LastFetchTime
+	dst.DesiredNodes = s.DesiredNodes
+	dst.CurrentNodes = s.CurrentNodes
+
+	dst.CurrentMetrics = withCurrentUtilization(dst.CurrentMetrics, bigtablev2.CPUMetricSourceType, s.CurrentCPUUtilization)
+	dst.CurrentMetrics = withCurrentUtilization(dst.CurrentMetrics, bigtablev2.HottestNodeCPUMetricSourceType,
+		s.CurrentHottestNodeCPUUtilization)
+
+	dst.Conditions = nil
+	for _, condition := range s.Conditions {
+		dst.Conditions = append(dst.Conditions, bigtablev2.Condition(condition))
+	}
+}
+
+func (s *BigtableAutoscalerStatus) convertFrom(src *bigtablev2.BigtableAutoscalerStatus) {
+	s.LastScaleTime = src.LastScaleTime
+	s.LastFetchTime = src.LastFetchTime
+	s.DesiredNodes = src.DesiredNodes
+	s.CurrentNodes = src.CurrentNodes
+
+	s.CurrentCPUUtilization = currentUtilization(src.CurrentMetrics, bigtablev2.CPUMetricSourceType)
+	s.CurrentHottestNodeCPUUtilization = currentUtilization(src.CurrentMetrics, bigtablev2.HottestNodeCPUMetricSourceType)
+
+	s.Conditions = nil
+	for _, condition := range src.Conditions {
+		s.Conditions = append(s.Conditions, Condition(condition))
+	}
+}
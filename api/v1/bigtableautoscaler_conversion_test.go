@@ -0,0 +1,206 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+func v2Autoscaler() *bigtablev2.BigtableAutoscaler {
+	return &bigtablev2.BigtableAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "autoscaler", Namespace: "default"},
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			MinNodes:          pointer.Int32(1),
+			MaxNodes:          pointer.Int32(10),
+			MaxScaleDownNodes: pointer.Int32(2),
+			Metrics: []bigtablev2.MetricSpec{
+				{
+					Type: bigtablev2.CPUMetricSourceType,
+					Target: bigtablev2.MetricTarget{
+						Type:               bigtablev2.UtilizationMetricType,
+						AverageUtilization: pointer.Int32(50),
+					},
+				},
+			},
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
+				ProjectID:  "cool-project",
+				InstanceID: "my-instance-id",
+				ClusterID:  "my-cluster-id",
+			},
+			ServiceAccountSecretRef: &bigtablev2.ServiceAccountSecretRef{
+				Name: pointer.String("example-service-account"),
+				Key:  pointer.String("service-account"),
+			},
+		},
+		Status: bigtablev2.BigtableAutoscalerStatus{
+			CurrentNodes: pointer.Int32(3),
+			CurrentMetrics: []bigtablev2.MetricStatus{
+				{
+					Type:    bigtablev2.CPUMetricSourceType,
+					Current: bigtablev2.MetricValueStatus{AverageUtilization: pointer.Int32(42)},
+				},
+			},
+		},
+	}
+}
+
+func TestConvertFrom(t *testing.T) {
+	var autoscaler bigtablev1.BigtableAutoscaler
+
+	err := autoscaler.ConvertFrom(v2Autoscaler())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(50), *autoscaler.Spec.TargetCPUUtilization)
+	assert.Equal(t, int32(42), *autoscaler.Status.CurrentCPUUtilization)
+	assert.Equal(t, "my-cluster-id", autoscaler.Spec.BigtableClusterRef.ClusterID)
+	assert.NotContains(t, autoscaler.Annotations, bigtablev1.V2SpecAnnotation)
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	averageValue := resource.MustParse("1k")
+
+	tests := map[string]func(autoscaler *bigtablev2.BigtableAutoscaler){
+		"cpu only": func(autoscaler *bigtablev2.BigtableAutoscaler) {},
+		"external metric": func(autoscaler *bigtablev2.BigtableAutoscaler) {
+			autoscaler.Spec.Metrics = append(autoscaler.Spec.Metrics, bigtablev2.MetricSpec{
+				Type: bigtablev2.ExternalMetricSourceType,
+				External: &bigtablev2.ExternalMetricSource{
+					MetricType: "pubsub.googleapis.com/subscription/num_undelivered_messages",
+				},
+				Target: bigtablev2.MetricTarget{
+					Type:         bigtablev2.AverageValueMetricType,
+					AverageValue: &averageValue,
+				},
+			})
+		},
+		"no cpu metric": func(autoscaler *bigtablev2.BigtableAutoscaler) {
+			autoscaler.Spec.Metrics[0].Type = bigtablev2.StorageMetricSourceType
+		},
+		"no secret": func(autoscaler *bigtablev2.BigtableAutoscaler) {
+			autoscaler.Spec.ServiceAccountSecretRef = nil
+		},
+		"suspended": func(autoscaler *bigtablev2.BigtableAutoscaler) {
+			autoscaler.Spec.Suspend = true
+		},
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			original := v2Autoscaler()
+			mutate(original)
+
+			var v1Autoscaler bigtablev1.BigtableAutoscaler
+			assert.NoError(t, v1Autoscaler.ConvertFrom(original.DeepCopy()))
+
+			var converted bigtablev2.BigtableAutoscaler
+			assert.NoError(t, v1Autoscaler.ConvertTo(&converted))
+
+			assert.Equal(t, original.Spec, converted.Spec)
+			assert.True(t, equality.Semantic.DeepEqual(original.Status, converted.Status), "status differs: %v", converted.Status)
+			assert.Equal(t, original.ObjectMeta, converted.ObjectMeta)
+		})
+	}
+}
+
+func TestConvertToKeepsV1Changes(t *testing.T) {
+	original := v2Autoscaler()
+	original.Spec.Metrics = append(original.Spec.Metrics, bigtablev2.MetricSpec{
+		Type: bigtablev2.HottestNodeCPUMetricSourceType,
+		Target: bigtablev2.MetricTarget{
+			Type:               bigtablev2.UtilizationMetricType,
+			AverageUtilization: pointer.Int32(90),
+		},
+	})
+
+	var v1Autoscaler bigtablev1.BigtableAutoscaler
+	assert.NoError(t, v1Autoscaler.ConvertFrom(original))
+
+	v1Autoscaler.Spec.TargetCPUUtilization = pointer.Int32(70)
+	v1Autoscaler.Spec.MaxNodes = pointer.Int32(20)
+
+	var converted bigtablev2.BigtableAutoscaler
+	assert.NoError(t, v1Autoscaler.ConvertTo(&converted))
+
+	assert.Equal(t, int32(20), *converted.Spec.MaxNodes)
+	if assert.Len(t, converted.Spec.Metrics, 2) {
+		assert.Equal(t, int32(70), *converted.Spec.Metrics[0].Target.AverageUtilization)
+		assert.Equal(t, bigtablev2.HottestNodeCPUMetricSourceType, converted.Spec.Metrics[1].Type)
+	}
+}
+
+func TestConvertDropsV2OnlyStatus(t *testing.T) {
+	fetchTime := metav1.NewTime(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+
+	original := v2Autoscaler()
+	original.Status.LastFetchTime = &fetchTime
+	original.Status.CurrentMetrics = append(original.Status.CurrentMetrics, bigtablev2.MetricStatus{
+		Type:    bigtablev2.StorageMetricSourceType,
+		Current: bigtablev2.MetricValueStatus{AverageUtilization: pointer.Int32(64)},
+	})
+	original.Status.RecommendedNodes = pointer.Int32(4)
+	original.Status.ScaleEvents = []bigtablev2.ScaleEvent{{Time: fetchTime, Change: 2}}
+
+	var v1Autoscaler bigtablev1.BigtableAutoscaler
+	assert.NoError(t, v1Autoscaler.ConvertFrom(original))
+	assert.Equal(t, original.Annotations, v1Autoscaler.Annotations)
+
+	var converted bigtablev2.BigtableAutoscaler
+	assert.NoError(t, v1Autoscaler.ConvertTo(&converted))
+
+	assert.Equal(t, int32(3), *converted.Status.CurrentNodes)
+	assert.True(t, fetchTime.Equal(converted.Status.LastFetchTime))
+	if assert.Len(t, converted.Status.CurrentMetrics, 1) {
+		assert.Equal(t, int32(42), *converted.Status.CurrentMetrics[0].Current.AverageUtilization)
+	}
+	assert.Nil(t, converted.Status.RecommendedNodes)
+	assert.Empty(t, converted.Status.ScaleEvents)
+}
+
+func TestConvertToHottestNodeCPU(t *testing.T) {
+	var v1Autoscaler bigtablev1.BigtableAutoscaler
+	assert.NoError(t, v1Autoscaler.ConvertFrom(v2Autoscaler()))
+	v1Autoscaler.Spec.TargetHottestNodeCPUUtilization = pointer.Int32(80)
+
+	var converted bigtablev2.BigtableAutoscaler
+	assert.NoError(t, v1Autoscaler.ConvertTo(&converted))
+
+	if assert.Len(t, converted.Spec.Metrics, 2) {
+		assert.Equal(t, bigtablev2.HottestNodeCPUMetricSourceType, converted.Spec.Metrics[1].Type)
+		assert.Equal(t, bigtablev2.UtilizationMetricType, converted.Spec.Metrics[1].Target.Type)
+		assert.Equal(t, int32(80), *converted.Spec.Metrics[1].Target.AverageUtilization)
+	}
+
+	assert.NoError(t, v1Autoscaler.ConvertFrom(&converted))
+	assert.Equal(t, int32(80), *v1Autoscaler.Spec.TargetHottestNodeCPUUtilization)
+	assert.NotContains(t, v1Autoscaler.Annotations, bigtablev1.V2SpecAnnotation)
+
+	v1Autoscaler.Spec.TargetHottestNodeCPUUtilization = nil
+	converted = bigtablev2.BigtableAutoscaler{}
+	assert.NoError(t, v1Autoscaler.ConvertTo(&converted))
+
+	assert.Len(t, converted.Spec.Metrics, 1)
+}
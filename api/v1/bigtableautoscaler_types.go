@@ -56,11 +56,20 @@ type BigtableAutoscalerSpec struct {
 	// target average CPU utilization for Bigtable.
 	TargetCPUUtilization *int32 `json:"targetCPUUtilization"`
 
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	// target CPU utilization of the busiest node. When set, the highest number of nodes
+	// required by either CPU target is used.
+	TargetHottestNodeCPUUtilization *int32 `json:"targetHottestNodeCPUUtilization,omitempty"`
+
 	// reference to the bigtable cluster to be autoscaled
 	BigtableClusterRef BigtableClusterRef `json:"bigtableClusterRef"`
 
-	// reference to the service account to be used to get bigtable metrics
-	ServiceAccountSecretRef ServiceAccountSecretRef `json:"serviceAccountSecretRef"`
+	// +optional
+	// reference to the service account key used to get bigtable metrics and scale the cluster.
+	// When not set, Application Default Credentials are used, e.g. GKE Workload Identity.
+	ServiceAccountSecretRef *ServiceAccountSecretRef `json:"serviceAccountSecretRef,omitempty"`
 }
 
 // BigtableAutoscalerStatus defines the observed state of BigtableAutoscaler
@@ -78,6 +87,48 @@ type BigtableAutoscalerStatus struct {
 
 	// +kubebuilder:default:=0
 	CurrentCPUUtilization *int32 `json:"CPUUtilization,omitempty"`
+
+	// +optional
+	// CPU utilization of the busiest node, read when TargetHottestNodeCPUUtilization is set.
+	CurrentHottestNodeCPUUtilization *int32 `json:"hottestNodeCPUUtilization,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	// latest available observations of the autoscaler's state.
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// Condition contains details for one aspect of the current state of the autoscaler.
+// It mirrors metav1.Condition, which is not available in the apimachinery version in use.
+type Condition struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=316
+	// type of condition in CamelCase.
+	Type string `json:"type"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	// status of the condition, one of True, False, Unknown.
+	Status metav1.ConditionStatus `json:"status"`
+
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	// the .metadata.generation that the condition was set based upon.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=1024
+	// programmatic identifier in CamelCase indicating the reason for the last transition.
+	Reason string `json:"reason"`
+
+	// +kubebuilder:validation:MaxLength=32768
+	// human readable message with details about the transition.
+	Message string `json:"message"`
 }
 
 type BigtableClusterRef struct {
@@ -93,6 +144,9 @@ type BigtableClusterRef struct {
 // +kubebuilder:printcolumn:name="desired_nodes",type=string,JSONPath=`.status.desiredNodes`
 // +kubebuilder:printcolumn:name="cpu_usage",type=string,JSONPath=`.status.CPUUtilization`
 // +kubebuilder:printcolumn:name="target_cpu",type=string,JSONPath=`.spec.targetCPUUtilization`
+// +kubebuilder:printcolumn:name="ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="limited",type=string,JSONPath=`.status.conditions[?(@.type=="ScalingLimited")].status`
+// +kubebuilder:printcolumn:name="reason",type=string,priority=1,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
 // +kubebuilder:subresource:status
 
 // BigtableAutoscaler is the Schema for the bigtableautoscalers API
@@ -0,0 +1,161 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+// v1 objects are defaulted and validated by the v2 webhook after being converted, so these tests
+// check that the v1 fields keep being defaulted and validated that way.
+
+func validAutoscaler() *bigtablev1.BigtableAutoscaler {
+	return &bigtablev1.BigtableAutoscaler{
+		Spec: bigtablev1.BigtableAutoscalerSpec{
+			MinNodes:             pointer.Int32(1),
+			MaxNodes:             pointer.Int32(10),
+			TargetCPUUtilization: pointer.Int32(50),
+			BigtableClusterRef: bigtablev1.BigtableClusterRef{
+				ProjectID:  "cool-project",
+				InstanceID: "my-instance-id",
+				ClusterID:  "my-cluster-id",
+			},
+			ServiceAccountSecretRef: &bigtablev1.ServiceAccountSecretRef{
+				Name: pointer.String("example-service-account"),
+				Key:  pointer.String("service-account"),
+			},
+		},
+	}
+}
+
+func TestDefault(t *testing.T) {
+	tests := map[string]struct {
+		maxScaleDownNodes *int32
+		expected          int32
+	}{
+		"unset max scale down nodes": {maxScaleDownNodes: nil, expected: 2},
+		"zero max scale down nodes":  {maxScaleDownNodes: pointer.Int32(0), expected: 2},
+		"keeps max scale down nodes": {maxScaleDownNodes: pointer.Int32(5), expected: 5},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			autoscaler := validAutoscaler()
+			autoscaler.Spec.MaxScaleDownNodes = test.maxScaleDownNodes
+
+			var hub bigtablev2.BigtableAutoscaler
+			assert.NoError(t, autoscaler.ConvertTo(&hub))
+			hub.Default()
+			assert.NoError(t, autoscaler.ConvertFrom(&hub))
+
+			if assert.NotNil(t, autoscaler.Spec.MaxScaleDownNodes) {
+				assert.Equal(t, test.expected, *autoscaler.Spec.MaxScaleDownNodes)
+			}
+			assert.Equal(t, int32(50), *autoscaler.Spec.TargetCPUUtilization)
+		})
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := map[string]struct {
+		mutate        func(autoscaler *bigtablev1.BigtableAutoscaler)
+		expectedField string
+	}{
+		"valid spec": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {},
+		},
+		"min nodes greater than max nodes": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.MinNodes = pointer.Int32(11)
+			},
+			expectedField: "spec.maxNodes",
+		},
+		"target cpu above 100": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.TargetCPUUtilization = pointer.Int32(101)
+			},
+			expectedField: "spec.metrics[0].target.averageUtilization",
+		},
+		"target cpu below 1": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.TargetCPUUtilization = pointer.Int32(0)
+			},
+			expectedField: "spec.metrics[0].target.averageUtilization",
+		},
+		"empty project id": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.BigtableClusterRef.ProjectID = ""
+			},
+			expectedField: "spec.bigtableClusterRef.projectId",
+		},
+		"empty instance id": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.BigtableClusterRef.InstanceID = ""
+			},
+			expectedField: "spec.bigtableClusterRef.instanceId",
+		},
+		"empty cluster id": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.BigtableClusterRef.ClusterID = ""
+			},
+			expectedField: "spec.bigtableClusterRef.clusterId",
+		},
+		"missing secret name": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.ServiceAccountSecretRef.Name = nil
+			},
+			expectedField: "spec.serviceAccountSecretRef.name",
+		},
+		"empty secret key": {
+			mutate: func(autoscaler *bigtablev1.BigtableAutoscaler) {
+				autoscaler.Spec.ServiceAccountSecretRef.Key = pointer.String("")
+			},
+			expectedField: "spec.serviceAccountSecretRef.key",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			autoscaler := validAutoscaler()
+			test.mutate(autoscaler)
+
+			var hub bigtablev2.BigtableAutoscaler
+			assert.NoError(t, autoscaler.ConvertTo(&hub))
+			hub.Default()
+
+			err := hub.ValidateCreate()
+
+			if test.expectedField == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.True(t, apierrors.IsInvalid(err))
+				assert.Contains(t, err.Error(), test.expectedField)
+			}
+		})
+	}
+}
@@ -106,8 +106,17 @@ func (in *BigtableAutoscalerSpec) DeepCopyInto(out *BigtableAutoscalerSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TargetHottestNodeCPUUtilization != nil {
+		in, out := &in.TargetHottestNodeCPUUtilization, &out.TargetHottestNodeCPUUtilization
+		*out = new(int32)
+		**out = **in
+	}
 	out.BigtableClusterRef = in.BigtableClusterRef
-	in.ServiceAccountSecretRef.DeepCopyInto(&out.ServiceAccountSecretRef)
+	if in.ServiceAccountSecretRef != nil {
+		in, out := &in.ServiceAccountSecretRef, &out.ServiceAccountSecretRef
+		*out = new(ServiceAccountSecretRef)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableAutoscalerSpec.
@@ -146,6 +155,18 @@ func (in *BigtableAutoscalerStatus) DeepCopyInto(out *BigtableAutoscalerStatus)
 		*out = new(int32)
 		**out = **in
 	}
+	if in.CurrentHottestNodeCPUUtilization != nil {
+		in, out := &in.CurrentHottestNodeCPUUtilization, &out.CurrentHottestNodeCPUUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableAutoscalerStatus.
@@ -173,6 +194,22 @@ func (in *BigtableClusterRef) DeepCopy() *BigtableClusterRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceAccountSecretRef) DeepCopyInto(out *ServiceAccountSecretRef) {
 	*out = *in
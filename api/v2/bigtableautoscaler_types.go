@@ -0,0 +1,794 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ServiceAccountSecretRef struct {
+	// +kubebuilder:validation:MinLength=1
+	Name *string `json:"name"`
+
+	Namespace *string `json:"namespace,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+	Key *string `json:"key"`
+}
+
+// BigtableAutoscalerSpec defines the desired state of BigtableAutoscaler
+type BigtableAutoscalerSpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// +kubebuilder:validation:Minimum=1
+	// lower limit for the number of nodes that can be set by the autoscaler.
+	MinNodes *int32 `json:"minNodes"`
+
+	// +kubebuilder:validation:Minimum=1
+	// upper limit for the number of nodes that can be set by the autoscaler.
+	// It cannot be smaller than MinNodes.
+	MaxNodes *int32 `json:"maxNodes"`
+
+	// +kubebuilder:default:=2
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	// upper limit for the number of nodes when autoscaler scaledown.
+	MaxScaleDownNodes *int32 `json:"maxScaleDownNodes"`
+
+	// +kubebuilder:validation:MinItems=1
+	// metrics used to calculate the desired number of nodes. A number of nodes
+	// is calculated for each metric and the highest one is used.
+	Metrics []MetricSpec `json:"metrics"`
+
+	// +optional
+	// how far back the metrics are read, e.g. "5m". Defaults to 5 minutes.
+	MetricWindow *metav1.Duration `json:"metricWindow,omitempty"`
+
+	// +optional
+	// period the metric values are aligned to within the window, e.g. "1m". It can't be
+	// shorter than a minute nor longer than metricWindow. Defaults to 1 minute.
+	AlignmentPeriod *metav1.Duration `json:"alignmentPeriod,omitempty"`
+
+	// +optional
+	// scaling behavior in the up and down directions.
+	Behavior *BigtableAutoscalerBehavior `json:"behavior,omitempty"`
+
+	// +optional
+	// whether the autoscaler resizes the cluster (Enforce) or only records the number of
+	// nodes it would scale to in status and events (Recommend). Defaults to Enforce.
+	Mode AutoscalerMode `json:"mode,omitempty"`
+
+	// +optional
+	// how the number of nodes required by the metrics is computed. Defaults to Linear, which
+	// scales the current number of nodes by the ratio between each metric and its target.
+	Strategy ScalingStrategy `json:"strategy,omitempty"`
+
+	// +optional
+	// tuning of the PID strategy. Only allowed when strategy is PID.
+	PID *PIDStrategy `json:"pid,omitempty"`
+
+	// +optional
+	// steps of the Steps strategy. Required when strategy is Steps, and only allowed then.
+	Steps []ScalingStep `json:"steps,omitempty"`
+
+	// +optional
+	// tuning of the Predictive strategy. Only allowed when strategy is Predictive.
+	Predictive *PredictiveStrategy `json:"predictive,omitempty"`
+
+	// +optional
+	// freezes the number of nodes of the cluster. Metrics are still read and the desired
+	// number of nodes still computed, but the cluster is not resized.
+	Suspend bool `json:"suspend,omitempty"`
+
+	// +optional
+	// how long scaling is held off after the number of nodes was changed outside of the
+	// autoscaler, e.g. in the console, before it is reconciled again. Zero disables the
+	// detection of such changes. Defaults to 30m.
+	ManualOverrideGracePeriod *metav1.Duration `json:"manualOverrideGracePeriod,omitempty"`
+
+	// +listType=atomic
+	// +optional
+	// periods overriding minNodes, maxNodes or the CPU target, e.g. to provision capacity
+	// before a daily peak. When several schedules are active, the first one listed wins.
+	Schedules []Schedule `json:"schedules,omitempty"`
+
+	// +listType=atomic
+	// +optional
+	// periods during which the cluster is never scaled down, e.g. release freezes. Scaling
+	// up is still allowed.
+	ScaleDownBlackouts []ScaleDownBlackout `json:"scaleDownBlackouts,omitempty"`
+
+	// +optional
+	// what is done to the cluster when the autoscaler is deleted. Defaults to leaving it as it is.
+	OnDelete *OnDeletePolicy `json:"onDelete,omitempty"`
+
+	// reference to the bigtable cluster to be autoscaled
+	BigtableClusterRef BigtableClusterRef `json:"bigtableClusterRef"`
+
+	// +optional
+	// reference to the service account key used to get bigtable metrics and scale the cluster.
+	// When not set, Application Default Credentials are used, e.g. GKE Workload Identity.
+	ServiceAccountSecretRef *ServiceAccountSecretRef `json:"serviceAccountSecretRef,omitempty"`
+
+	// +optional
+	// email of a service account impersonated to get bigtable metrics and scale the cluster,
+	// using the credentials above. The operator's identity needs the Service Account Token
+	// Creator role on it.
+	ImpersonateServiceAccount string `json:"impersonateServiceAccount,omitempty"`
+
+	// +listType=atomic
+	// +optional
+	// emails of the service accounts in the delegation chain to impersonateServiceAccount,
+	// each one able to impersonate the next one.
+	ImpersonationDelegates []string `json:"impersonationDelegates,omitempty"`
+}
+
+// MetricSourceType indicates the source of a metric.
+// +kubebuilder:validation:Enum=CPU;HottestNodeCPU;Storage;External
+type MetricSourceType string
+
+const (
+	// CPUMetricSourceType is the average CPU utilization of the cluster.
+	CPUMetricSourceType MetricSourceType = "CPU"
+
+	// HottestNodeCPUMetricSourceType is the CPU utilization of the busiest node of the cluster.
+	HottestNodeCPUMetricSourceType MetricSourceType = "HottestNodeCPU"
+
+	// StorageMetricSourceType is the storage utilization of the cluster.
+	StorageMetricSourceType MetricSourceType = "Storage"
+
+	// ExternalMetricSourceType is an arbitrary Cloud Monitoring metric.
+	ExternalMetricSourceType MetricSourceType = "External"
+)
+
+// MetricSpec specifies a metric to scale on and its target value.
+type MetricSpec struct {
+	// type of the metric source.
+	Type MetricSourceType `json:"type"`
+
+	// +optional
+	// Cloud Monitoring metric to scale on. Required when type is External.
+	External *ExternalMetricSource `json:"external,omitempty"`
+
+	// +optional
+	// how the values of the metric within the window are combined. Defaults to Latest.
+	Aggregation MetricAggregation `json:"aggregation,omitempty"`
+
+	// target value for the metric.
+	Target MetricTarget `json:"target"`
+}
+
+// MetricAggregation specifies how the values of a metric within the window are combined.
+// +kubebuilder:validation:Enum=Latest;Mean;Max;P95
+type MetricAggregation string
+
+const (
+	// LatestMetricAggregation uses the latest value of the metric.
+	LatestMetricAggregation MetricAggregation = "Latest"
+
+	// MeanMetricAggregation uses the mean of the values within the window.
+	MeanMetricAggregation MetricAggregation = "Mean"
+
+	// MaxMetricAggregation uses the highest value within the window.
+	MaxMetricAggregation MetricAggregation = "Max"
+
+	// P95MetricAggregation uses the 95th percentile of the values within the window.
+	P95MetricAggregation MetricAggregation = "P95"
+)
+
+// ExternalMetricSource identifies a Cloud Monitoring metric.
+type ExternalMetricSource struct {
+	// +kubebuilder:validation:MinLength=1
+	// Cloud Monitoring metric type, e.g. "pubsub.googleapis.com/subscription/num_undelivered_messages".
+	MetricType string `json:"metricType"`
+
+	// +optional
+	// additional Cloud Monitoring filter, combined with the metric type using AND.
+	Filter string `json:"filter,omitempty"`
+
+	// +optional
+	// kind of the Cloud Monitoring metric. The values of Delta and Cumulative metrics are read as
+	// their rate of change per second. Defaults to Gauge.
+	MetricKind MetricKind `json:"metricKind,omitempty"`
+}
+
+// MetricKind specifies the kind of a Cloud Monitoring metric.
+// +kubebuilder:validation:Enum=Gauge;Delta;Cumulative
+type MetricKind string
+
+const (
+	// GaugeMetricKind is a metric whose values are measured at a point in time.
+	GaugeMetricKind MetricKind = "Gauge"
+
+	// DeltaMetricKind is a metric whose values are the change since the previous point.
+	DeltaMetricKind MetricKind = "Delta"
+
+	// CumulativeMetricKind is a metric whose values accumulate since a start time.
+	CumulativeMetricKind MetricKind = "Cumulative"
+)
+
+// MetricTargetType specifies how a metric is compared to its target.
+// +kubebuilder:validation:Enum=Utilization;Value;AverageValue
+type MetricTargetType string
+
+const (
+	// UtilizationMetricType targets a percentage, used by the CPU, HottestNodeCPU and Storage metrics.
+	UtilizationMetricType MetricTargetType = "Utilization"
+
+	// ValueMetricType targets the value of the metric as a whole.
+	ValueMetricType MetricTargetType = "Value"
+
+	// AverageValueMetricType targets the value of the metric divided by the number of nodes.
+	AverageValueMetricType MetricTargetType = "AverageValue"
+)
+
+// MetricTarget defines the target value of a metric.
+type MetricTarget struct {
+	// whether the target is a utilization, a value or an average value.
+	Type MetricTargetType `json:"type"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	// target utilization, in percent. Used when type is Utilization.
+	AverageUtilization *int32 `json:"averageUtilization,omitempty"`
+
+	// +optional
+	// target value of the metric. Used when type is Value.
+	Value *resource.Quantity `json:"value,omitempty"`
+
+	// +optional
+	// target value of the metric per node. Used when type is AverageValue.
+	AverageValue *resource.Quantity `json:"averageValue,omitempty"`
+}
+
+// BigtableAutoscalerBehavior configures the scaling behavior in the up and down directions.
+type BigtableAutoscalerBehavior struct {
+	// +optional
+	// rules used when scaling up.
+	ScaleUp *ScalingRules `json:"scaleUp,omitempty"`
+
+	// +optional
+	// rules used when scaling down.
+	ScaleDown *ScalingRules `json:"scaleDown,omitempty"`
+}
+
+// ScalingRules configures the scaling behavior in one direction.
+type ScalingRules struct {
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=3600
+	// +optional
+	// number of seconds for which past recommendations are considered when scaling.
+	// When scaling up, the lowest recommendation within the window is used; when scaling
+	// down, the highest one.
+	StabilizationWindowSeconds *int32 `json:"stabilizationWindowSeconds,omitempty"`
+
+	// +optional
+	// which of the policies is used. Max picks the one allowing the biggest change, Min the
+	// one allowing the smallest change and Disabled turns off scaling in this direction.
+	// Defaults to Max.
+	SelectPolicy *ScalingPolicySelect `json:"selectPolicy,omitempty"`
+
+	// +listType=atomic
+	// +optional
+	// limits on the change of nodes within a period. When none is set, the change is not limited.
+	Policies []ScalingPolicy `json:"policies,omitempty"`
+}
+
+// ScalingPolicySelect chooses which of the scaling policies is used.
+// +kubebuilder:validation:Enum=Max;Min;Disabled
+type ScalingPolicySelect string
+
+const (
+	// MaxPolicySelect selects the policy allowing the biggest change.
+	MaxPolicySelect ScalingPolicySelect = "Max"
+
+	// MinPolicySelect selects the policy allowing the smallest change.
+	MinPolicySelect ScalingPolicySelect = "Min"
+
+	// DisabledPolicySelect disables scaling in the direction.
+	DisabledPolicySelect ScalingPolicySelect = "Disabled"
+)
+
+// ScalingPolicyType is the unit of a scaling policy.
+// +kubebuilder:validation:Enum=Nodes;Percent
+type ScalingPolicyType string
+
+const (
+	// NodesScalingPolicy limits the change to an absolute number of nodes.
+	NodesScalingPolicy ScalingPolicyType = "Nodes"
+
+	// PercentScalingPolicy limits the change to a percentage of the nodes at the start of the period.
+	PercentScalingPolicy ScalingPolicyType = "Percent"
+)
+
+// ScalingPolicy limits the change of nodes within a period.
+type ScalingPolicy struct {
+	// unit of the policy value.
+	Type ScalingPolicyType `json:"type"`
+
+	// +kubebuilder:validation:Minimum=1
+	// number of nodes or percentage of nodes the cluster can change by within the period.
+	Value int32 `json:"value"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1800
+	// length of the period, in seconds.
+	PeriodSeconds int32 `json:"periodSeconds"`
+}
+
+// AutoscalerMode tells whether the autoscaler resizes the cluster.
+// +kubebuilder:validation:Enum=Enforce;Recommend
+type AutoscalerMode string
+
+const (
+	// EnforceAutoscalerMode resizes the cluster to the desired number of nodes.
+	EnforceAutoscalerMode AutoscalerMode = "Enforce"
+
+	// RecommendAutoscalerMode only records the desired number of nodes, without resizing the cluster.
+	RecommendAutoscalerMode AutoscalerMode = "Recommend"
+)
+
+// ScalingStrategy is the algorithm recommending the number of nodes from the metrics.
+// +kubebuilder:validation:Enum=Linear;PID;Steps;Predictive
+type ScalingStrategy string
+
+const (
+	// LinearScalingStrategy scales the current number of nodes by the ratio between the
+	// current value of each metric and its target, following the metric requiring the most nodes.
+	LinearScalingStrategy ScalingStrategy = "Linear"
+
+	// PIDScalingStrategy scales the current number of nodes by the output of a PID controller
+	// on the relative error between the metric requiring the most nodes and its target.
+	PIDScalingStrategy ScalingStrategy = "PID"
+
+	// StepsScalingStrategy adds or removes a fixed number of nodes when the metric requiring the
+	// most nodes crosses the thresholds of the steps.
+	StepsScalingStrategy ScalingStrategy = "Steps"
+
+	// PredictiveScalingStrategy follows the trend of the number of nodes the Linear strategy
+	// required over the recent samples, so that the cluster is scaled up before the load arrives.
+	PredictiveScalingStrategy ScalingStrategy = "Predictive"
+)
+
+// PIDStrategy holds the gains of the PID strategy. The error is the relative distance of the
+// metric requiring the most nodes to its target, e.g. 0.5 for 75% of CPU against a 50% target,
+// and the output is the fraction of the current nodes added or removed. With only a
+// proportional gain of 1, the PID strategy recommends what the Linear one does.
+type PIDStrategy struct {
+	// +optional
+	// fraction of the current nodes added per unit of error. Defaults to 1.
+	ProportionalGain *resource.Quantity `json:"proportionalGain,omitempty"`
+
+	// +optional
+	// fraction of the current nodes added per unit of error sustained for a minute. Defaults to 0.1.
+	IntegralGain *resource.Quantity `json:"integralGain,omitempty"`
+
+	// +optional
+	// fraction of the current nodes added per unit of error gained within a minute. Defaults to 0.
+	DerivativeGain *resource.Quantity `json:"derivativeGain,omitempty"`
+
+	// +optional
+	// largest fraction of the current nodes the integral term adds or removes, so that it
+	// doesn't wind up while the cluster is held at its limits. Defaults to 0.5.
+	IntegralLimit *resource.Quantity `json:"integralLimit,omitempty"`
+
+	// +optional
+	// percentage of the previous derivative kept when a sample is read, from 0 to 99, to damp
+	// the noise of the metrics. Defaults to 50.
+	DerivativeSmoothing *int32 `json:"derivativeSmoothing,omitempty"`
+}
+
+// ScalingStep adds or removes nodes once the metric requiring the most nodes crosses a threshold,
+// in percent of its target. Steps with a threshold above 100 scale up once the metric reaches it,
+// and steps with a threshold below 100 scale down once the metric falls to it. Of the thresholds
+// crossed, the one farthest from the target applies.
+type ScalingStep struct {
+	// +kubebuilder:validation:Minimum=0
+	// value of the metric in percent of its target, e.g. 150 for 75% of CPU against a 50% target.
+	Threshold int32 `json:"threshold"`
+
+	// nodes added, or removed when negative.
+	Change int32 `json:"change"`
+}
+
+// PredictiveStrategy holds the tuning of the Predictive strategy.
+type PredictiveStrategy struct {
+	// +optional
+	// how far ahead the trend of the required nodes is followed. Defaults to 5m.
+	Horizon *metav1.Duration `json:"horizon,omitempty"`
+}
+
+// OnDeleteAction is what is done to the cluster when the autoscaler is deleted.
+// +kubebuilder:validation:Enum=Leave;ScaleToMin;ScaleTo
+type OnDeleteAction string
+
+const (
+	// LeaveOnDeleteAction leaves the cluster with the number of nodes it has.
+	LeaveOnDeleteAction OnDeleteAction = "Leave"
+
+	// ScaleToMinOnDeleteAction scales the cluster to minNodes, or to the nodes needed to hold
+	// the stored data when that is higher.
+	ScaleToMinOnDeleteAction OnDeleteAction = "ScaleToMin"
+
+	// ScaleToOnDeleteAction scales the cluster to a fixed number of nodes.
+	ScaleToOnDeleteAction OnDeleteAction = "ScaleTo"
+)
+
+// Schedule overrides the limits and the CPU target of the autoscaler during recurring periods.
+type Schedule struct {
+	// name of the schedule, shown in status while it is active.
+	Name string `json:"name"`
+
+	// when the schedule starts, as a standard cron expression with five fields,
+	// e.g. "30 6 * * 1-5" for 6:30 on weekdays.
+	Cron string `json:"cron"`
+
+	// +optional
+	// IANA time zone of the cron expression, e.g. "America/Sao_Paulo". Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// how long the schedule stays active after each start.
+	Duration metav1.Duration `json:"duration"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	// minimum number of nodes while the schedule is active. maxNodes is raised to it if needed.
+	MinNodes *int32 `json:"minNodes,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	// maximum number of nodes while the schedule is active.
+	MaxNodes *int32 `json:"maxNodes,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	// average CPU utilization target of the CPU metric while the schedule is active, in percent.
+	TargetCPUUtilization *int32 `json:"targetCPUUtilization,omitempty"`
+}
+
+// ScaleDownBlackout is a period during which the cluster is never scaled down. It either recurs,
+// with cron and duration, or is an absolute range, with startTime and endTime.
+type ScaleDownBlackout struct {
+	// name of the blackout, shown in status and events when it blocks a scale down.
+	Name string `json:"name"`
+
+	// +optional
+	// when the blackout starts, as a standard cron expression with five fields.
+	Cron string `json:"cron,omitempty"`
+
+	// +optional
+	// IANA time zone of the cron expression. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// +optional
+	// how long the blackout lasts after each start. Required along with cron.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// +optional
+	// when the blackout starts. Required along with endTime, when cron is not set.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	// when the blackout ends.
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+}
+
+// OnDeletePolicy configures what is done to the cluster when the autoscaler is deleted.
+type OnDeletePolicy struct {
+	// action run before the autoscaler is removed.
+	Action OnDeleteAction `json:"action"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	// number of nodes the cluster is scaled to. Required when action is ScaleTo.
+	Nodes *int32 `json:"nodes,omitempty"`
+}
+
+// ManualOverride is a change of the number of nodes made outside of the autoscaler.
+type ManualOverride struct {
+	// number of nodes the cluster was set to.
+	Nodes int32 `json:"nodes"`
+
+	// when the change was detected.
+	DetectedTime metav1.Time `json:"detectedTime"`
+}
+
+// MetricSample holds the metrics read at a point in time, with the number of nodes they were read with.
+type MetricSample struct {
+	// time the metrics were read.
+	Time metav1.Time `json:"time"`
+
+	// number of nodes of the cluster when the metrics were read.
+	Nodes int32 `json:"nodes"`
+
+	// +optional
+	// values of the metrics, in the same order as spec.metrics.
+	Metrics []MetricStatus `json:"metrics,omitempty"`
+}
+
+// PIDStatus is the state the PID strategy keeps between samples.
+type PIDStatus struct {
+	// sum of the errors over time, in error-minutes.
+	Integral resource.Quantity `json:"integral"`
+
+	// smoothed rate of change of the error, per minute.
+	Derivative resource.Quantity `json:"derivative"`
+
+	// error of the last sample.
+	LastError resource.Quantity `json:"lastError"`
+
+	// time of the last sample.
+	LastSampleTime metav1.Time `json:"lastSampleTime"`
+}
+
+// BigtableAutoscalerStatus defines the observed state of BigtableAutoscaler
+type BigtableAutoscalerStatus struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// +optional
+	// time of the last scale operation. Metrics fetched before it are not used to scale again.
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+	LastFetchTime *metav1.Time `json:"lastFetchTime,omitempty"`
+
+	// +kubebuilder:default:=0
+	DesiredNodes *int32 `json:"desiredNodes,omitempty"`
+
+	// +kubebuilder:default:=0
+	CurrentNodes *int32 `json:"currentNodes,omitempty"`
+
+	// +optional
+	// last read values of the metrics, in the same order as spec.metrics.
+	CurrentMetrics []MetricStatus `json:"currentMetrics,omitempty"`
+
+	// +optional
+	// storage utilization of the cluster, in percent of the storage limit of its nodes.
+	CurrentStorageUtilization *int32 `json:"currentStorageUtilization,omitempty"`
+
+	// +optional
+	// lowest number of nodes the autoscaler scales to: the highest of minNodes and the
+	// number of nodes needed to hold the stored data.
+	EffectiveMinNodes *int32 `json:"effectiveMinNodes,omitempty"`
+
+	// +optional
+	// recommended number of nodes within the longest stabilization window, oldest first.
+	// Each recommendation stands until the next one.
+	Recommendations []Recommendation `json:"recommendations,omitempty"`
+
+	// +optional
+	// metrics read within the last 10 minutes, at most one sample per minute, oldest first.
+	// The strategies following the trend of the metrics read them.
+	Samples []MetricSample `json:"samples,omitempty"`
+
+	// +optional
+	// changes of nodes made by the autoscaler within the longest policy period, oldest first.
+	ScaleEvents []ScaleEvent `json:"scaleEvents,omitempty"`
+
+	// +optional
+	// lowest recommendation within the scale up stabilization window.
+	ScaleUpRecommendation *int32 `json:"scaleUpRecommendation,omitempty"`
+
+	// +optional
+	// highest recommendation within the scale down stabilization window.
+	ScaleDownRecommendation *int32 `json:"scaleDownRecommendation,omitempty"`
+
+	// +optional
+	// where the credentials used to reach Google Cloud come from: Secret, when
+	// serviceAccountSecretRef is set, or ApplicationDefault.
+	CredentialsSource string `json:"credentialsSource,omitempty"`
+
+	// +optional
+	// service account the credentials impersonate, when impersonateServiceAccount is set.
+	ImpersonatedServiceAccount string `json:"impersonatedServiceAccount,omitempty"`
+
+	// +optional
+	// number of nodes the autoscaler would scale to. Only set in Recommend mode, where the
+	// cluster is not resized.
+	RecommendedNodes *int32 `json:"recommendedNodes,omitempty"`
+
+	// +optional
+	// why RecommendedNodes was recommended.
+	RecommendationReason string `json:"recommendationReason,omitempty"`
+
+	// +optional
+	// name of the schedule whose overrides are in effect, if any.
+	ActiveSchedule string `json:"activeSchedule,omitempty"`
+
+	// +optional
+	// highest floor of the capacity reservations in effect, if any.
+	ReservedNodes *int32 `json:"reservedNodes,omitempty"`
+
+	// +optional
+	// last number of nodes the autoscaler set the cluster to, or observed when it started
+	// managing it. A different number of nodes is a manual override.
+	LastAppliedNodes *int32 `json:"lastAppliedNodes,omitempty"`
+
+	// +optional
+	// last change of the number of nodes made outside of the autoscaler, until the autoscaler
+	// takes the cluster over again.
+	ManualOverride *ManualOverride `json:"manualOverride,omitempty"`
+
+	// +optional
+	// state of the PID strategy, kept so that it survives restarts of the operator.
+	PID *PIDStatus `json:"pid,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	// latest available observations of the autoscaler's state.
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// Recommendation is a number of nodes required by the metrics at a point in time.
+type Recommendation struct {
+	// time the recommendation was first made.
+	Time metav1.Time `json:"time"`
+
+	// recommended number of nodes.
+	Nodes int32 `json:"nodes"`
+}
+
+// ScaleEvent is a change of nodes made by the autoscaler.
+type ScaleEvent struct {
+	// time the cluster was scaled.
+	Time metav1.Time `json:"time"`
+
+	// number of nodes added, or removed when negative.
+	Change int32 `json:"change"`
+}
+
+// MetricStatus describes the last read value of a metric.
+type MetricStatus struct {
+	// type of the metric source.
+	Type MetricSourceType `json:"type"`
+
+	// +optional
+	// Cloud Monitoring metric that was read. Set when type is External.
+	External *ExternalMetricSource `json:"external,omitempty"`
+
+	// current value of the metric.
+	Current MetricValueStatus `json:"current"`
+}
+
+// MetricValueStatus holds the current value of a metric.
+type MetricValueStatus struct {
+	// +optional
+	// current utilization, in percent.
+	AverageUtilization *int32 `json:"averageUtilization,omitempty"`
+
+	// +optional
+	// current value of the metric.
+	Value *resource.Quantity `json:"value,omitempty"`
+
+	// +optional
+	// current value of the metric per node.
+	AverageValue *resource.Quantity `json:"averageValue,omitempty"`
+}
+
+// Condition types reported in BigtableAutoscalerStatus.Conditions.
+const (
+	// ConditionReady summarizes the other conditions: it is True when
+	// credentials are valid, metrics are available and scaling is active.
+	ConditionReady = "Ready"
+
+	// ConditionMetricsAvailable tells whether the last metrics sync succeeded.
+	ConditionMetricsAvailable = "MetricsAvailable"
+
+	// ConditionScalingActive tells whether the autoscaler is able to compute
+	// and apply the desired number of nodes.
+	ConditionScalingActive = "ScalingActive"
+
+	// ConditionScalingLimited is True when the desired number of nodes was
+	// clamped by MinNodes, MaxNodes or the storage floor, or when the cluster
+	// is held at its size by a blackout, the stabilization windows or the
+	// scaling policies.
+	ConditionScalingLimited = "ScalingLimited"
+
+	// ConditionCredentialsValid tells whether the Google Cloud clients could
+	// be built from the referenced credentials.
+	ConditionCredentialsValid = "CredentialsValid"
+
+	// ConditionSuspended is True when spec.suspend is set and the cluster is
+	// not resized, although metrics are still read and recommendations made.
+	ConditionSuspended = "Suspended"
+
+	// ConditionManualOverride is True while scaling is held off because the
+	// number of nodes was changed outside of the autoscaler.
+	ConditionManualOverride = "ManualOverride"
+)
+
+// Condition contains details for one aspect of the current state of the autoscaler.
+// It mirrors metav1.Condition, which is not available in the apimachinery version in use.
+type Condition struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=316
+	// type of condition in CamelCase.
+	Type string `json:"type"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	// status of the condition, one of True, False, Unknown.
+	Status metav1.ConditionStatus `json:"status"`
+
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	// the .metadata.generation that the condition was set based upon.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=1024
+	// programmatic identifier in CamelCase indicating the reason for the last transition.
+	Reason string `json:"reason"`
+
+	// +kubebuilder:validation:MaxLength=32768
+	// human readable message with details about the transition.
+	Message string `json:"message"`
+}
+
+type BigtableClusterRef struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	ProjectID  string `json:"projectId,omitempty"`
+	InstanceID string `json:"instanceId,omitempty"`
+	ClusterID  string `json:"clusterId,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="nodes",type=string,JSONPath=`.status.currentNodes`
+// +kubebuilder:printcolumn:name="desired_nodes",type=string,JSONPath=`.status.desiredNodes`
+// +kubebuilder:printcolumn:name="cpu_usage",type=string,JSONPath=`.status.currentMetrics[?(@.type=="CPU")].current.averageUtilization`
+// +kubebuilder:printcolumn:name="target_cpu",type=string,JSONPath=`.spec.metrics[?(@.type=="CPU")].target.averageUtilization`
+// +kubebuilder:printcolumn:name="hottest_node_cpu",type=string,priority=1,JSONPath=`.status.currentMetrics[?(@.type=="HottestNodeCPU")].current.averageUtilization`
+// +kubebuilder:printcolumn:name="min_nodes",type=string,priority=1,JSONPath=`.status.effectiveMinNodes`
+// +kubebuilder:printcolumn:name="ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="limited",type=string,JSONPath=`.status.conditions[?(@.type=="ScalingLimited")].status`
+// +kubebuilder:printcolumn:name="mode",type=string,priority=1,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="recommended_nodes",type=string,priority=1,JSONPath=`.status.recommendedNodes`
+// +kubebuilder:printcolumn:name="suspended",type=string,JSONPath=`.status.conditions[?(@.type=="Suspended")].status`
+// +kubebuilder:printcolumn:name="schedule",type=string,priority=1,JSONPath=`.status.activeSchedule`
+// +kubebuilder:printcolumn:name="overridden",type=string,priority=1,JSONPath=`.status.conditions[?(@.type=="ManualOverride")].status`
+// +kubebuilder:printcolumn:name="reason",type=string,priority=1,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:subresource:status
+
+// BigtableAutoscaler is the Schema for the bigtableautoscalers API
+type BigtableAutoscaler struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BigtableAutoscalerSpec   `json:"spec,omitempty"`
+	Status BigtableAutoscalerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BigtableAutoscalerList contains a list of BigtableAutoscaler
+type BigtableAutoscalerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BigtableAutoscaler `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BigtableAutoscaler{}, &BigtableAutoscalerList{})
+}
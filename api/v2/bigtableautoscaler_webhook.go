@@ -0,0 +1,785 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *BigtableAutoscaler) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+const (
+	// DefaultMaxScaleDownNodes is the scale down step used when MaxScaleDownNodes is not set.
+	DefaultMaxScaleDownNodes int32 = 2
+
+	// DefaultScaleUpStabilizationWindowSeconds is the scale up stabilization window used when it is not set.
+	// As with the HPA, scaling up is not delayed by default.
+	DefaultScaleUpStabilizationWindowSeconds int32 = 0
+
+	// DefaultScaleDownStabilizationWindowSeconds is the scale down stabilization window used when it is not set.
+	DefaultScaleDownStabilizationWindowSeconds int32 = 60
+
+	// MaxStabilizationWindowSeconds is the longest stabilization window allowed.
+	MaxStabilizationWindowSeconds int32 = 3600
+
+	// MaxPolicyPeriodSeconds is the longest scaling policy period allowed.
+	MaxPolicyPeriodSeconds int32 = 1800
+
+	// DefaultMetricWindow is how far back the metrics are read when MetricWindow is not set.
+	DefaultMetricWindow = 5 * time.Minute
+
+	// MaxMetricWindow is the longest metric window allowed.
+	MaxMetricWindow = 1 * time.Hour
+
+	// DefaultAlignmentPeriod is the alignment period used when AlignmentPeriod is not set.
+	DefaultAlignmentPeriod = 1 * time.Minute
+
+	// MinAlignmentPeriod is the shortest alignment period Cloud Monitoring accepts.
+	MinAlignmentPeriod = 1 * time.Minute
+
+	// DefaultManualOverrideGracePeriod is how long scaling is held off after a manual change of
+	// the number of nodes when ManualOverrideGracePeriod is not set.
+	DefaultManualOverrideGracePeriod = 30 * time.Minute
+
+	// DefaultPIDProportionalGain is the proportional gain of the PID strategy when it is not set.
+	DefaultPIDProportionalGain = "1"
+
+	// DefaultPIDIntegralGain is the integral gain of the PID strategy when it is not set.
+	DefaultPIDIntegralGain = "0.1"
+
+	// DefaultPIDDerivativeGain is the derivative gain of the PID strategy when it is not set.
+	DefaultPIDDerivativeGain = "0"
+
+	// DefaultPIDIntegralLimit is the bound of the integral term of the PID strategy when it is not set.
+	DefaultPIDIntegralLimit = "0.5"
+
+	// DefaultPIDDerivativeSmoothing is the derivative smoothing of the PID strategy when it is not set.
+	DefaultPIDDerivativeSmoothing int32 = 50
+
+	// DefaultPredictiveHorizon is how far ahead the Predictive strategy looks when it is not set.
+	DefaultPredictiveHorizon = 5 * time.Minute
+
+	// MaxPredictiveHorizon is the furthest the Predictive strategy is allowed to look ahead.
+	MaxPredictiveHorizon = 30 * time.Minute
+)
+
+// The webhooks are only registered for v2. Their default Equivalent match policy makes the
+// API server convert v1 requests to v2 before calling them.
+
+// +kubebuilder:webhook:path=/mutate-bigtable-bigtable-autoscaler-com-v2-bigtableautoscaler,mutating=true,failurePolicy=fail,sideEffects=None,groups=bigtable.bigtable-autoscaler.com,resources=bigtableautoscalers,verbs=create;update,versions=v2,name=mbigtableautoscaler.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &BigtableAutoscaler{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *BigtableAutoscaler) Default() {
+	if r.Spec.MaxScaleDownNodes == nil || *r.Spec.MaxScaleDownNodes == 0 {
+		maxScaleDownNodes := DefaultMaxScaleDownNodes
+		r.Spec.MaxScaleDownNodes = &maxScaleDownNodes
+	}
+
+	for i := range r.Spec.Metrics {
+		metric := &r.Spec.Metrics[i]
+		if metric.Target.Type == "" && metric.Type != ExternalMetricSourceType {
+			metric.Target.Type = UtilizationMetricType
+		}
+
+		if metric.Aggregation == "" {
+			metric.Aggregation = LatestMetricAggregation
+		}
+	}
+
+	if r.Spec.Mode == "" {
+		r.Spec.Mode = EnforceAutoscalerMode
+	}
+
+	if r.Spec.Strategy == "" {
+		r.Spec.Strategy = LinearScalingStrategy
+	}
+
+	if r.Spec.Strategy == PIDScalingStrategy {
+		r.Spec.PID = defaultPIDStrategy(r.Spec.PID)
+	}
+
+	if r.Spec.Strategy == PredictiveScalingStrategy {
+		if r.Spec.Predictive == nil {
+			r.Spec.Predictive = &PredictiveStrategy{}
+		}
+
+		if r.Spec.Predictive.Horizon == nil {
+			r.Spec.Predictive.Horizon = &metav1.Duration{Duration: DefaultPredictiveHorizon}
+		}
+	}
+
+	if r.Spec.MetricWindow == nil {
+		r.Spec.MetricWindow = &metav1.Duration{Duration: DefaultMetricWindow}
+	}
+
+	if r.Spec.AlignmentPeriod == nil {
+		r.Spec.AlignmentPeriod = &metav1.Duration{Duration: DefaultAlignmentPeriod}
+	}
+
+	if r.Spec.ManualOverrideGracePeriod == nil {
+		r.Spec.ManualOverrideGracePeriod = &metav1.Duration{Duration: DefaultManualOverrideGracePeriod}
+	}
+
+	if r.Spec.Behavior == nil {
+		r.Spec.Behavior = &BigtableAutoscalerBehavior{}
+	}
+	r.Spec.Behavior.ScaleUp = defaultScalingRules(r.Spec.Behavior.ScaleUp, DefaultScaleUpStabilizationWindowSeconds)
+	r.Spec.Behavior.ScaleDown = defaultScalingRules(r.Spec.Behavior.ScaleDown, DefaultScaleDownStabilizationWindowSeconds)
+}
+
+func defaultPIDStrategy(pid *PIDStrategy) *PIDStrategy {
+	if pid == nil {
+		pid = &PIDStrategy{}
+	}
+
+	defaultQuantity := func(q **resource.Quantity, value string) {
+		if *q == nil {
+			quantity := resource.MustParse(value)
+			*q = &quantity
+		}
+	}
+	defaultQuantity(&pid.ProportionalGain, DefaultPIDProportionalGain)
+	defaultQuantity(&pid.IntegralGain, DefaultPIDIntegralGain)
+	defaultQuantity(&pid.DerivativeGain, DefaultPIDDerivativeGain)
+	defaultQuantity(&pid.IntegralLimit, DefaultPIDIntegralLimit)
+
+	if pid.DerivativeSmoothing == nil {
+		smoothing := DefaultPIDDerivativeSmoothing
+		pid.DerivativeSmoothing = &smoothing
+	}
+
+	return pid
+}
+
+func defaultScalingRules(rules *ScalingRules, stabilizationWindowSeconds int32) *ScalingRules {
+	if rules == nil {
+		rules = &ScalingRules{}
+	}
+
+	if rules.StabilizationWindowSeconds == nil {
+		rules.StabilizationWindowSeconds = &stabilizationWindowSeconds
+	}
+
+	if rules.SelectPolicy == nil {
+		selectPolicy := MaxPolicySelect
+		rules.SelectPolicy = &selectPolicy
+	}
+
+	return rules
+}
+
+// +kubebuilder:webhook:path=/validate-bigtable-bigtable-autoscaler-com-v2-bigtableautoscaler,mutating=false,failurePolicy=fail,sideEffects=None,groups=bigtable.bigtable-autoscaler.com,resources=bigtableautoscalers,verbs=create;update,versions=v2,name=vbigtableautoscaler.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &BigtableAutoscaler{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *BigtableAutoscaler) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *BigtableAutoscaler) ValidateUpdate(old runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *BigtableAutoscaler) ValidateDelete() error {
+	return nil
+}
+
+func (r *BigtableAutoscaler) validate() error {
+	allErrs := r.Spec.validate(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("BigtableAutoscaler").GroupKind(), r.Name, allErrs)
+}
+
+func (s *BigtableAutoscalerSpec) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if s.MinNodes == nil {
+		allErrs = append(allErrs, field.Required(path.Child("minNodes"), "must be set"))
+	}
+
+	if s.MaxNodes == nil {
+		allErrs = append(allErrs, field.Required(path.Child("maxNodes"), "must be set"))
+	}
+
+	if s.MinNodes != nil && s.MaxNodes != nil && *s.MinNodes > *s.MaxNodes {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxNodes"), *s.MaxNodes, "must be greater than or equal to minNodes"))
+	}
+
+	allErrs = append(allErrs, validateMetrics(s.Metrics, path.Child("metrics"))...)
+	allErrs = append(allErrs, s.validateMetricWindow(path)...)
+
+	if s.Behavior != nil {
+		allErrs = append(allErrs, s.Behavior.validate(path.Child("behavior"))...)
+	}
+
+	switch s.Mode {
+	case "", EnforceAutoscalerMode, RecommendAutoscalerMode:
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("mode"), s.Mode, []string{
+			string(EnforceAutoscalerMode),
+			string(RecommendAutoscalerMode),
+		}))
+	}
+
+	switch s.Strategy {
+	case "", LinearScalingStrategy, PIDScalingStrategy, StepsScalingStrategy, PredictiveScalingStrategy:
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("strategy"), s.Strategy, []string{
+			string(LinearScalingStrategy),
+			string(PIDScalingStrategy),
+			string(StepsScalingStrategy),
+			string(PredictiveScalingStrategy),
+		}))
+	}
+
+	if s.PID != nil {
+		if s.Strategy != PIDScalingStrategy {
+			allErrs = append(allErrs, field.Forbidden(path.Child("pid"), "may only be set when strategy is PID"))
+		}
+		allErrs = append(allErrs, s.PID.validate(path.Child("pid"))...)
+	}
+
+	if s.Strategy == StepsScalingStrategy && len(s.Steps) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("steps"), "must be set when strategy is Steps"))
+	}
+
+	if len(s.Steps) > 0 && s.Strategy != StepsScalingStrategy {
+		allErrs = append(allErrs, field.Forbidden(path.Child("steps"), "may only be set when strategy is Steps"))
+	}
+
+	for i, step := range s.Steps {
+		allErrs = append(allErrs, step.validate(path.Child("steps").Index(i))...)
+	}
+
+	if s.Predictive != nil {
+		if s.Strategy != PredictiveScalingStrategy {
+			allErrs = append(allErrs, field.Forbidden(path.Child("predictive"), "may only be set when strategy is Predictive"))
+		}
+		allErrs = append(allErrs, s.Predictive.validate(path.Child("predictive"))...)
+	}
+
+	if s.ManualOverrideGracePeriod != nil && s.ManualOverrideGracePeriod.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("manualOverrideGracePeriod"),
+			s.ManualOverrideGracePeriod.Duration.String(), "must not be negative"))
+	}
+
+	allErrs = append(allErrs, validateSchedules(s.Schedules, s.Metrics, path.Child("schedules"))...)
+	allErrs = append(allErrs, validateScaleDownBlackouts(s.ScaleDownBlackouts, path.Child("scaleDownBlackouts"))...)
+
+	if s.OnDelete != nil {
+		allErrs = append(allErrs, s.OnDelete.validate(path.Child("onDelete"))...)
+	}
+
+	allErrs = append(allErrs, s.BigtableClusterRef.validate(path.Child("bigtableClusterRef"))...)
+	if s.ServiceAccountSecretRef != nil {
+		allErrs = append(allErrs, s.ServiceAccountSecretRef.validate(path.Child("serviceAccountSecretRef"))...)
+	}
+
+	allErrs = append(allErrs, s.validateImpersonation(path)...)
+
+	return allErrs
+}
+
+func validateMetrics(metrics []MetricSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(metrics) == 0 {
+		return append(allErrs, field.Required(path, "must contain at least one metric"))
+	}
+
+	seen := make(map[MetricSourceType]bool)
+
+	for i, metric := range metrics {
+		metricPath := path.Index(i)
+
+		switch metric.Aggregation {
+		case "", LatestMetricAggregation, MeanMetricAggregation, MaxMetricAggregation, P95MetricAggregation:
+		default:
+			allErrs = append(allErrs, field.NotSupported(metricPath.Child("aggregation"), metric.Aggregation, []string{
+				string(LatestMetricAggregation),
+				string(MeanMetricAggregation),
+				string(MaxMetricAggregation),
+				string(P95MetricAggregation),
+			}))
+		}
+
+		switch metric.Type {
+		case CPUMetricSourceType, HottestNodeCPUMetricSourceType, StorageMetricSourceType:
+			if seen[metric.Type] {
+				allErrs = append(allErrs, field.Duplicate(metricPath.Child("type"), metric.Type))
+			}
+			seen[metric.Type] = true
+
+			if metric.External != nil {
+				allErrs = append(allErrs, field.Forbidden(metricPath.Child("external"), "must only be set when type is External"))
+			}
+
+			allErrs = append(allErrs, validateUtilizationTarget(metric.Target, metricPath.Child("target"))...)
+		case ExternalMetricSourceType:
+			if metric.External == nil || metric.External.MetricType == "" {
+				allErrs = append(allErrs, field.Required(metricPath.Child("external", "metricType"), "must be set when type is External"))
+			}
+
+			allErrs = append(allErrs, validateValueTarget(metric.Target, metricPath.Child("target"))...)
+		default:
+			allErrs = append(allErrs, field.NotSupported(metricPath.Child("type"), metric.Type, []string{
+				string(CPUMetricSourceType),
+				string(HottestNodeCPUMetricSourceType),
+				string(StorageMetricSourceType),
+				string(ExternalMetricSourceType),
+			}))
+		}
+	}
+
+	return allErrs
+}
+
+func (s *BigtableAutoscalerSpec) validateMetricWindow(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	window := DefaultMetricWindow
+	if s.MetricWindow != nil {
+		window = s.MetricWindow.Duration
+	}
+
+	alignmentPeriod := DefaultAlignmentPeriod
+	if s.AlignmentPeriod != nil {
+		alignmentPeriod = s.AlignmentPeriod.Duration
+	}
+
+	if window <= 0 || window > MaxMetricWindow {
+		allErrs = append(allErrs, field.Invalid(path.Child("metricWindow"), window.String(),
+			fmt.Sprintf("must be positive and at most %s", MaxMetricWindow)))
+	}
+
+	if alignmentPeriod < MinAlignmentPeriod {
+		allErrs = append(allErrs, field.Invalid(path.Child("alignmentPeriod"), alignmentPeriod.String(),
+			fmt.Sprintf("must be at least %s", MinAlignmentPeriod)))
+	} else if alignmentPeriod > window {
+		allErrs = append(allErrs, field.Invalid(path.Child("alignmentPeriod"), alignmentPeriod.String(),
+			"must not be longer than metricWindow"))
+	}
+
+	return allErrs
+}
+
+func validateUtilizationTarget(target MetricTarget, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if target.Type != UtilizationMetricType {
+		return append(allErrs, field.NotSupported(path.Child("type"), target.Type, []string{string(UtilizationMetricType)}))
+	}
+
+	if target.AverageUtilization == nil {
+		allErrs = append(allErrs, field.Required(path.Child("averageUtilization"), "must be set"))
+	} else if *target.AverageUtilization < 1 || *target.AverageUtilization > 100 {
+		allErrs = append(allErrs, field.Invalid(path.Child("averageUtilization"), *target.AverageUtilization, "must be between 1 and 100"))
+	}
+
+	return allErrs
+}
+
+func validateValueTarget(target MetricTarget, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch target.Type {
+	case ValueMetricType:
+		if target.Value == nil {
+			allErrs = append(allErrs, field.Required(path.Child("value"), "must be set"))
+		} else if target.Value.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("value"), target.Value.String(), "must be positive"))
+		}
+	case AverageValueMetricType:
+		if target.AverageValue == nil {
+			allErrs = append(allErrs, field.Required(path.Child("averageValue"), "must be set"))
+		} else if target.AverageValue.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("averageValue"), target.AverageValue.String(), "must be positive"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("type"), target.Type, []string{
+			string(ValueMetricType),
+			string(AverageValueMetricType),
+		}))
+	}
+
+	return allErrs
+}
+
+func (b *BigtableAutoscalerBehavior) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if b.ScaleUp != nil {
+		allErrs = append(allErrs, b.ScaleUp.validate(path.Child("scaleUp"))...)
+	}
+
+	if b.ScaleDown != nil {
+		allErrs = append(allErrs, b.ScaleDown.validate(path.Child("scaleDown"))...)
+	}
+
+	return allErrs
+}
+
+func (p *PIDStrategy) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	quantities := []struct {
+		name  string
+		value *resource.Quantity
+	}{
+		{"proportionalGain", p.ProportionalGain},
+		{"integralGain", p.IntegralGain},
+		{"derivativeGain", p.DerivativeGain},
+		{"integralLimit", p.IntegralLimit},
+	}
+	for _, q := range quantities {
+		if q.value != nil && q.value.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child(q.name), q.value.String(), "must not be negative"))
+		}
+	}
+
+	if p.DerivativeSmoothing != nil && (*p.DerivativeSmoothing < 0 || *p.DerivativeSmoothing > 99) {
+		allErrs = append(allErrs, field.Invalid(path.Child("derivativeSmoothing"), *p.DerivativeSmoothing,
+			"must be between 0 and 99"))
+	}
+
+	return allErrs
+}
+
+func (s *ScalingStep) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch {
+	case s.Threshold < 0 || s.Threshold == 100:
+		allErrs = append(allErrs, field.Invalid(path.Child("threshold"), s.Threshold,
+			"must be above 100 to scale up or between 0 and 100 to scale down"))
+	case s.Threshold > 100 && s.Change <= 0:
+		allErrs = append(allErrs, field.Invalid(path.Child("change"), s.Change, "must be positive above the target"))
+	case s.Threshold < 100 && s.Change >= 0:
+		allErrs = append(allErrs, field.Invalid(path.Child("change"), s.Change, "must be negative below the target"))
+	}
+
+	return allErrs
+}
+
+func (p *PredictiveStrategy) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if p.Horizon != nil && (p.Horizon.Duration <= 0 || p.Horizon.Duration > MaxPredictiveHorizon) {
+		allErrs = append(allErrs, field.Invalid(path.Child("horizon"), p.Horizon.Duration.String(),
+			fmt.Sprintf("must be positive and at most %s", MaxPredictiveHorizon)))
+	}
+
+	return allErrs
+}
+
+func (r *ScalingRules) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	window := r.StabilizationWindowSeconds
+	if window != nil && (*window < 0 || *window > MaxStabilizationWindowSeconds) {
+		allErrs = append(allErrs, field.Invalid(path.Child("stabilizationWindowSeconds"), *window,
+			fmt.Sprintf("must be between 0 and %d", MaxStabilizationWindowSeconds)))
+	}
+
+	if r.SelectPolicy != nil {
+		switch *r.SelectPolicy {
+		case MaxPolicySelect, MinPolicySelect, DisabledPolicySelect:
+		default:
+			allErrs = append(allErrs, field.NotSupported(path.Child("selectPolicy"), *r.SelectPolicy, []string{
+				string(MaxPolicySelect),
+				string(MinPolicySelect),
+				string(DisabledPolicySelect),
+			}))
+		}
+	}
+
+	for i, policy := range r.Policies {
+		allErrs = append(allErrs, policy.validate(path.Child("policies").Index(i))...)
+	}
+
+	return allErrs
+}
+
+func (p *ScalingPolicy) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if p.Type != NodesScalingPolicy && p.Type != PercentScalingPolicy {
+		allErrs = append(allErrs, field.NotSupported(path.Child("type"), p.Type, []string{
+			string(NodesScalingPolicy),
+			string(PercentScalingPolicy),
+		}))
+	}
+
+	if p.Value < 1 {
+		allErrs = append(allErrs, field.Invalid(path.Child("value"), p.Value, "must be greater than zero"))
+	}
+
+	if p.PeriodSeconds < 1 || p.PeriodSeconds > MaxPolicyPeriodSeconds {
+		allErrs = append(allErrs, field.Invalid(path.Child("periodSeconds"), p.PeriodSeconds,
+			fmt.Sprintf("must be between 1 and %d", MaxPolicyPeriodSeconds)))
+	}
+
+	return allErrs
+}
+
+func validateSchedules(schedules []Schedule, metrics []MetricSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	hasCPUMetric := false
+	for _, metric := range metrics {
+		hasCPUMetric = hasCPUMetric || metric.Type == CPUMetricSourceType
+	}
+
+	names := make(map[string]bool)
+
+	for i := range schedules {
+		schedule := &schedules[i]
+		schedulePath := path.Index(i)
+
+		if schedule.Name == "" {
+			allErrs = append(allErrs, field.Required(schedulePath.Child("name"), "must not be empty"))
+		} else if names[schedule.Name] {
+			allErrs = append(allErrs, field.Duplicate(schedulePath.Child("name"), schedule.Name))
+		}
+		names[schedule.Name] = true
+
+		allErrs = append(allErrs, schedule.validate(schedulePath)...)
+
+		if schedule.TargetCPUUtilization != nil && !hasCPUMetric {
+			allErrs = append(allErrs, field.Forbidden(schedulePath.Child("targetCPUUtilization"),
+				"must only be set when a CPU metric is set"))
+		}
+	}
+
+	return allErrs
+}
+
+func (s *Schedule) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, validateCron(s.Cron, s.TimeZone, path)...)
+
+	if s.Duration.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("duration"), s.Duration.Duration.String(), "must be positive"))
+	}
+
+	if s.MinNodes == nil && s.MaxNodes == nil && s.TargetCPUUtilization == nil {
+		allErrs = append(allErrs, field.Required(path,
+			"must override at least one of minNodes, maxNodes and targetCPUUtilization"))
+	}
+
+	if s.MinNodes != nil && *s.MinNodes < 1 {
+		allErrs = append(allErrs, field.Invalid(path.Child("minNodes"), *s.MinNodes, "must be greater than zero"))
+	}
+
+	if s.MaxNodes != nil && *s.MaxNodes < 1 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxNodes"), *s.MaxNodes, "must be greater than zero"))
+	}
+
+	if s.MinNodes != nil && s.MaxNodes != nil && *s.MinNodes > *s.MaxNodes {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxNodes"), *s.MaxNodes, "must be greater than or equal to minNodes"))
+	}
+
+	if s.TargetCPUUtilization != nil && (*s.TargetCPUUtilization < 1 || *s.TargetCPUUtilization > 100) {
+		allErrs = append(allErrs, field.Invalid(path.Child("targetCPUUtilization"), *s.TargetCPUUtilization,
+			"must be between 1 and 100"))
+	}
+
+	return allErrs
+}
+
+// validateCron checks the cron expression and time zone of a recurring period.
+func validateCron(expression, timeZone string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// The time zone has its own field, so it is not accepted as a prefix of the expression.
+	if strings.HasPrefix(expression, "TZ=") || strings.HasPrefix(expression, "CRON_TZ=") {
+		allErrs = append(allErrs, field.Invalid(path.Child("cron"), expression, "must not set the time zone; use timeZone"))
+	} else if _, err := cron.ParseStandard(expression); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("cron"), expression, err.Error()))
+	}
+
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("timeZone"), timeZone, "must be an IANA time zone"))
+	}
+
+	return allErrs
+}
+
+func validateScaleDownBlackouts(blackouts []ScaleDownBlackout, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := make(map[string]bool)
+
+	for i := range blackouts {
+		blackout := &blackouts[i]
+		blackoutPath := path.Index(i)
+
+		if blackout.Name == "" {
+			allErrs = append(allErrs, field.Required(blackoutPath.Child("name"), "must not be empty"))
+		} else if names[blackout.Name] {
+			allErrs = append(allErrs, field.Duplicate(blackoutPath.Child("name"), blackout.Name))
+		}
+		names[blackout.Name] = true
+
+		allErrs = append(allErrs, blackout.validate(blackoutPath)...)
+	}
+
+	return allErrs
+}
+
+func (b *ScaleDownBlackout) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	absolute := b.StartTime != nil || b.EndTime != nil
+
+	switch {
+	case b.Cron != "" && absolute:
+		allErrs = append(allErrs, field.Forbidden(path.Child("startTime"), "must not be set along with cron"))
+	case b.Cron != "":
+		allErrs = append(allErrs, validateCron(b.Cron, b.TimeZone, path)...)
+
+		if b.Duration == nil || b.Duration.Duration <= 0 {
+			allErrs = append(allErrs, field.Required(path.Child("duration"), "must be positive when cron is set"))
+		}
+	case absolute:
+		if b.StartTime == nil {
+			allErrs = append(allErrs, field.Required(path.Child("startTime"), "must be set along with endTime"))
+		} else if b.EndTime == nil {
+			allErrs = append(allErrs, field.Required(path.Child("endTime"), "must be set along with startTime"))
+		} else if !b.EndTime.After(b.StartTime.Time) {
+			allErrs = append(allErrs, field.Invalid(path.Child("endTime"), *b.EndTime, "must be after startTime"))
+		}
+
+		if b.TimeZone != "" || b.Duration != nil {
+			allErrs = append(allErrs, field.Forbidden(path, "timeZone and duration must only be set along with cron"))
+		}
+	default:
+		allErrs = append(allErrs, field.Required(path, "must set either cron or startTime and endTime"))
+	}
+
+	return allErrs
+}
+
+func (p *OnDeletePolicy) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch p.Action {
+	case LeaveOnDeleteAction, ScaleToMinOnDeleteAction:
+		if p.Nodes != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("nodes"), "must only be set when action is ScaleTo"))
+		}
+	case ScaleToOnDeleteAction:
+		if p.Nodes == nil {
+			allErrs = append(allErrs, field.Required(path.Child("nodes"), "must be set when action is ScaleTo"))
+		} else if *p.Nodes < 1 {
+			allErrs = append(allErrs, field.Invalid(path.Child("nodes"), *p.Nodes, "must be greater than zero"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("action"), p.Action, []string{
+			string(LeaveOnDeleteAction),
+			string(ScaleToMinOnDeleteAction),
+			string(ScaleToOnDeleteAction),
+		}))
+	}
+
+	return allErrs
+}
+
+func (s *BigtableAutoscalerSpec) validateImpersonation(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if s.ImpersonateServiceAccount != "" && !isServiceAccountEmail(s.ImpersonateServiceAccount) {
+		allErrs = append(allErrs, field.Invalid(path.Child("impersonateServiceAccount"), s.ImpersonateServiceAccount,
+			"must be a service account email"))
+	}
+
+	if len(s.ImpersonationDelegates) > 0 && s.ImpersonateServiceAccount == "" {
+		allErrs = append(allErrs, field.Forbidden(path.Child("impersonationDelegates"),
+			"must only be set along with impersonateServiceAccount"))
+	}
+
+	for i, delegate := range s.ImpersonationDelegates {
+		if !isServiceAccountEmail(delegate) {
+			allErrs = append(allErrs, field.Invalid(path.Child("impersonationDelegates").Index(i), delegate,
+				"must be a service account email"))
+		}
+	}
+
+	return allErrs
+}
+
+func isServiceAccountEmail(email string) bool {
+	at := strings.Index(email, "@")
+
+	return at > 0 && at == strings.LastIndex(email, "@") && at < len(email)-1
+}
+
+func (c *BigtableClusterRef) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if c.ProjectID == "" {
+		allErrs = append(allErrs, field.Required(path.Child("projectId"), "must not be empty"))
+	}
+
+	if c.InstanceID == "" {
+		allErrs = append(allErrs, field.Required(path.Child("instanceId"), "must not be empty"))
+	}
+
+	if c.ClusterID == "" {
+		allErrs = append(allErrs, field.Required(path.Child("clusterId"), "must not be empty"))
+	}
+
+	return allErrs
+}
+
+func (s *ServiceAccountSecretRef) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if s.Name == nil || *s.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), "must not be empty"))
+	}
+
+	if s.Key == nil || *s.Key == "" {
+		allErrs = append(allErrs, field.Required(path.Child("key"), "must not be empty"))
+	}
+
+	return allErrs
+}
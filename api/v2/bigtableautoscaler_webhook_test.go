@@ -0,0 +1,678 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+func validAutoscaler() *bigtablev2.BigtableAutoscaler {
+	return &bigtablev2.BigtableAutoscaler{
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			MinNodes: pointer.Int32(1),
+			MaxNodes: pointer.Int32(10),
+			Metrics: []bigtablev2.MetricSpec{
+				{
+					Type: bigtablev2.CPUMetricSourceType,
+					Target: bigtablev2.MetricTarget{
+						Type:               bigtablev2.UtilizationMetricType,
+						AverageUtilization: pointer.Int32(50),
+					},
+				},
+			},
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
+				ProjectID:  "cool-project",
+				InstanceID: "my-instance-id",
+				ClusterID:  "my-cluster-id",
+			},
+			ServiceAccountSecretRef: &bigtablev2.ServiceAccountSecretRef{
+				Name: pointer.String("example-service-account"),
+				Key:  pointer.String("service-account"),
+			},
+		},
+	}
+}
+
+func externalMetric(target bigtablev2.MetricTarget) bigtablev2.MetricSpec {
+	return bigtablev2.MetricSpec{
+		Type: bigtablev2.ExternalMetricSourceType,
+		External: &bigtablev2.ExternalMetricSource{
+			MetricType: "pubsub.googleapis.com/subscription/num_undelivered_messages",
+		},
+		Target: target,
+	}
+}
+
+func validSchedule() bigtablev2.Schedule {
+	return bigtablev2.Schedule{
+		Name:                 "morning-peak",
+		Cron:                 "30 6 * * 1-5",
+		TimeZone:             "America/Sao_Paulo",
+		Duration:             metav1.Duration{Duration: 3 * time.Hour},
+		MinNodes:             pointer.Int32(12),
+		TargetCPUUtilization: pointer.Int32(40),
+	}
+}
+
+func absoluteBlackout() bigtablev2.ScaleDownBlackout {
+	start := metav1.NewTime(time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(time.Date(2021, 12, 27, 0, 0, 0, 0, time.UTC))
+
+	return bigtablev2.ScaleDownBlackout{
+		Name:      "release-freeze",
+		StartTime: &start,
+		EndTime:   &end,
+	}
+}
+
+func TestDefault(t *testing.T) {
+	tests := map[string]struct {
+		maxScaleDownNodes *int32
+		expected          int32
+	}{
+		"unset max scale down nodes": {maxScaleDownNodes: nil, expected: 2},
+		"zero max scale down nodes":  {maxScaleDownNodes: pointer.Int32(0), expected: 2},
+		"keeps max scale down nodes": {maxScaleDownNodes: pointer.Int32(5), expected: 5},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			autoscaler := validAutoscaler()
+			autoscaler.Spec.MaxScaleDownNodes = test.maxScaleDownNodes
+
+			autoscaler.Default()
+
+			if assert.NotNil(t, autoscaler.Spec.MaxScaleDownNodes) {
+				assert.Equal(t, test.expected, *autoscaler.Spec.MaxScaleDownNodes)
+			}
+		})
+	}
+}
+
+func TestDefaultMetricTargetType(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Spec.Metrics[0].Target.Type = ""
+	autoscaler.Spec.Metrics = append(autoscaler.Spec.Metrics, externalMetric(bigtablev2.MetricTarget{}))
+
+	autoscaler.Default()
+
+	assert.Equal(t, bigtablev2.UtilizationMetricType, autoscaler.Spec.Metrics[0].Target.Type)
+	assert.Equal(t, bigtablev2.MetricTargetType(""), autoscaler.Spec.Metrics[1].Target.Type)
+	assert.Equal(t, bigtablev2.LatestMetricAggregation, autoscaler.Spec.Metrics[0].Aggregation)
+}
+
+func TestDefaultBehavior(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Spec.Behavior = &bigtablev2.BigtableAutoscalerBehavior{
+		ScaleDown: &bigtablev2.ScalingRules{StabilizationWindowSeconds: pointer.Int32(1800)},
+	}
+
+	autoscaler.Default()
+
+	assert.Equal(t, int32(0), *autoscaler.Spec.Behavior.ScaleUp.StabilizationWindowSeconds)
+	assert.Equal(t, int32(1800), *autoscaler.Spec.Behavior.ScaleDown.StabilizationWindowSeconds)
+	assert.Equal(t, bigtablev2.MaxPolicySelect, *autoscaler.Spec.Behavior.ScaleUp.SelectPolicy)
+	assert.Equal(t, bigtablev2.MaxPolicySelect, *autoscaler.Spec.Behavior.ScaleDown.SelectPolicy)
+}
+
+func TestDefaultMetricWindow(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Spec.MetricWindow = &metav1.Duration{Duration: 15 * time.Minute}
+
+	autoscaler.Default()
+
+	assert.Equal(t, 15*time.Minute, autoscaler.Spec.MetricWindow.Duration)
+	assert.Equal(t, time.Minute, autoscaler.Spec.AlignmentPeriod.Duration)
+}
+
+func TestDefaultManualOverrideGracePeriod(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Default()
+	assert.Equal(t, bigtablev2.DefaultManualOverrideGracePeriod, autoscaler.Spec.ManualOverrideGracePeriod.Duration)
+
+	autoscaler.Spec.ManualOverrideGracePeriod = &metav1.Duration{}
+	autoscaler.Default()
+	assert.Equal(t, time.Duration(0), autoscaler.Spec.ManualOverrideGracePeriod.Duration)
+}
+
+func TestDefaultMode(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Default()
+	assert.Equal(t, bigtablev2.EnforceAutoscalerMode, autoscaler.Spec.Mode)
+
+	autoscaler.Spec.Mode = bigtablev2.RecommendAutoscalerMode
+	autoscaler.Default()
+	assert.Equal(t, bigtablev2.RecommendAutoscalerMode, autoscaler.Spec.Mode)
+}
+
+func TestDefaultStrategy(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Default()
+	assert.Equal(t, bigtablev2.LinearScalingStrategy, autoscaler.Spec.Strategy)
+	assert.Nil(t, autoscaler.Spec.PID)
+}
+
+func TestDefaultPIDStrategy(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Spec.Strategy = bigtablev2.PIDScalingStrategy
+	autoscaler.Spec.PID = &bigtablev2.PIDStrategy{DerivativeGain: resource.NewMilliQuantity(200, resource.DecimalSI)}
+	autoscaler.Default()
+
+	if assert.NotNil(t, autoscaler.Spec.PID) {
+		assert.Equal(t, "1", autoscaler.Spec.PID.ProportionalGain.String())
+		assert.Equal(t, "100m", autoscaler.Spec.PID.IntegralGain.String())
+		assert.Equal(t, "200m", autoscaler.Spec.PID.DerivativeGain.String())
+		assert.Equal(t, "500m", autoscaler.Spec.PID.IntegralLimit.String())
+		assert.Equal(t, bigtablev2.DefaultPIDDerivativeSmoothing, *autoscaler.Spec.PID.DerivativeSmoothing)
+	}
+}
+
+func TestDefaultPredictiveStrategy(t *testing.T) {
+	autoscaler := validAutoscaler()
+	autoscaler.Spec.Strategy = bigtablev2.PredictiveScalingStrategy
+	autoscaler.Default()
+
+	if assert.NotNil(t, autoscaler.Spec.Predictive) {
+		assert.Equal(t, bigtablev2.DefaultPredictiveHorizon, autoscaler.Spec.Predictive.Horizon.Duration)
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := map[string]struct {
+		mutate        func(autoscaler *bigtablev2.BigtableAutoscaler)
+		expectedField string
+	}{
+		"valid spec": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {},
+		},
+		"valid external metric": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				value := resource.MustParse("1000")
+				autoscaler.Spec.Metrics = append(autoscaler.Spec.Metrics, externalMetric(bigtablev2.MetricTarget{
+					Type:         bigtablev2.AverageValueMetricType,
+					AverageValue: &value,
+				}))
+			},
+		},
+		"min nodes greater than max nodes": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.MinNodes = pointer.Int32(11)
+			},
+			expectedField: "spec.maxNodes",
+		},
+		"no metrics": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Metrics = nil
+			},
+			expectedField: "spec.metrics",
+		},
+		"target cpu above 100": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Metrics[0].Target.AverageUtilization = pointer.Int32(101)
+			},
+			expectedField: "spec.metrics[0].target.averageUtilization",
+		},
+		"target cpu below 1": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Metrics[0].Target.AverageUtilization = pointer.Int32(0)
+			},
+			expectedField: "spec.metrics[0].target.averageUtilization",
+		},
+		"unknown aggregation": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Metrics[0].Aggregation = "P99"
+			},
+			expectedField: "spec.metrics[0].aggregation",
+		},
+		"valid metric window": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.MetricWindow = &metav1.Duration{Duration: 30 * time.Minute}
+				autoscaler.Spec.AlignmentPeriod = &metav1.Duration{Duration: 5 * time.Minute}
+			},
+		},
+		"metric window above max": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.MetricWindow = &metav1.Duration{Duration: 2 * time.Hour}
+			},
+			expectedField: "spec.metricWindow",
+		},
+		"alignment period below a minute": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.AlignmentPeriod = &metav1.Duration{Duration: 30 * time.Second}
+			},
+			expectedField: "spec.alignmentPeriod",
+		},
+		"alignment period longer than metric window": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.AlignmentPeriod = &metav1.Duration{Duration: 10 * time.Minute}
+			},
+			expectedField: "spec.alignmentPeriod",
+		},
+		"disabled manual override detection": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ManualOverrideGracePeriod = &metav1.Duration{}
+			},
+		},
+		"negative manual override grace period": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ManualOverrideGracePeriod = &metav1.Duration{Duration: -time.Minute}
+			},
+			expectedField: "spec.manualOverrideGracePeriod",
+		},
+		"valid schedule": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{validSchedule()}
+			},
+		},
+		"schedule with invalid cron": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				schedule := validSchedule()
+				schedule.Cron = "every morning"
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{schedule}
+			},
+			expectedField: "spec.schedules[0].cron",
+		},
+		"schedule with time zone in cron": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				schedule := validSchedule()
+				schedule.Cron = "CRON_TZ=UTC 30 6 * * 1-5"
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{schedule}
+			},
+			expectedField: "spec.schedules[0].cron",
+		},
+		"schedule with unknown time zone": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				schedule := validSchedule()
+				schedule.TimeZone = "Mars/Olympus_Mons"
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{schedule}
+			},
+			expectedField: "spec.schedules[0].timeZone",
+		},
+		"schedule without duration": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				schedule := validSchedule()
+				schedule.Duration = metav1.Duration{}
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{schedule}
+			},
+			expectedField: "spec.schedules[0].duration",
+		},
+		"schedule without overrides": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				schedule := validSchedule()
+				schedule.MinNodes = nil
+				schedule.TargetCPUUtilization = nil
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{schedule}
+			},
+			expectedField: "spec.schedules[0]",
+		},
+		"schedule with min nodes above max nodes": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				schedule := validSchedule()
+				schedule.MaxNodes = pointer.Int32(5)
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{schedule}
+			},
+			expectedField: "spec.schedules[0].maxNodes",
+		},
+		"duplicated schedule name": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{validSchedule(), validSchedule()}
+			},
+			expectedField: "spec.schedules[1].name",
+		},
+		"schedule cpu target without cpu metric": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Metrics[0].Type = bigtablev2.StorageMetricSourceType
+				autoscaler.Spec.Schedules = []bigtablev2.Schedule{validSchedule()}
+			},
+			expectedField: "spec.schedules[0].targetCPUUtilization",
+		},
+		"recurring scale down blackout": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ScaleDownBlackouts = []bigtablev2.ScaleDownBlackout{{
+					Name:     "on-call-handover",
+					Cron:     "0 9 * * 1",
+					Duration: &metav1.Duration{Duration: time.Hour},
+				}}
+			},
+		},
+		"absolute scale down blackout": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ScaleDownBlackouts = []bigtablev2.ScaleDownBlackout{absoluteBlackout()}
+			},
+		},
+		"scale down blackout without period": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ScaleDownBlackouts = []bigtablev2.ScaleDownBlackout{{Name: "release-freeze"}}
+			},
+			expectedField: "spec.scaleDownBlackouts[0]",
+		},
+		"recurring scale down blackout without duration": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ScaleDownBlackouts = []bigtablev2.ScaleDownBlackout{{
+					Name: "on-call-handover",
+					Cron: "0 9 * * 1",
+				}}
+			},
+			expectedField: "spec.scaleDownBlackouts[0].duration",
+		},
+		"scale down blackout with cron and range": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				blackout := absoluteBlackout()
+				blackout.Cron = "0 9 * * 1"
+				autoscaler.Spec.ScaleDownBlackouts = []bigtablev2.ScaleDownBlackout{blackout}
+			},
+			expectedField: "spec.scaleDownBlackouts[0].startTime",
+		},
+		"scale down blackout ending before it starts": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				blackout := absoluteBlackout()
+				blackout.StartTime, blackout.EndTime = blackout.EndTime, blackout.StartTime
+				autoscaler.Spec.ScaleDownBlackouts = []bigtablev2.ScaleDownBlackout{blackout}
+			},
+			expectedField: "spec.scaleDownBlackouts[0].endTime",
+		},
+		"duplicated scale down blackout name": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ScaleDownBlackouts = []bigtablev2.ScaleDownBlackout{absoluteBlackout(), absoluteBlackout()}
+			},
+			expectedField: "spec.scaleDownBlackouts[1].name",
+		},
+		"duplicated cpu metric": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Metrics = append(autoscaler.Spec.Metrics, autoscaler.Spec.Metrics[0])
+			},
+			expectedField: "spec.metrics[1].type",
+		},
+		"external metric without metric type": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				value := resource.MustParse("10")
+				metric := externalMetric(bigtablev2.MetricTarget{Type: bigtablev2.ValueMetricType, Value: &value})
+				metric.External.MetricType = ""
+				autoscaler.Spec.Metrics = append(autoscaler.Spec.Metrics, metric)
+			},
+			expectedField: "spec.metrics[1].external.metricType",
+		},
+		"external metric with utilization target": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Metrics = append(autoscaler.Spec.Metrics, externalMetric(bigtablev2.MetricTarget{
+					Type:               bigtablev2.UtilizationMetricType,
+					AverageUtilization: pointer.Int32(50),
+				}))
+			},
+			expectedField: "spec.metrics[1].target.type",
+		},
+		"external metric with negative value": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				value := resource.MustParse("-1")
+				autoscaler.Spec.Metrics = append(autoscaler.Spec.Metrics, externalMetric(bigtablev2.MetricTarget{
+					Type:  bigtablev2.ValueMetricType,
+					Value: &value,
+				}))
+			},
+			expectedField: "spec.metrics[1].target.value",
+		},
+		"stabilization window above max": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Behavior = &bigtablev2.BigtableAutoscalerBehavior{
+					ScaleDown: &bigtablev2.ScalingRules{StabilizationWindowSeconds: pointer.Int32(3601)},
+				}
+			},
+			expectedField: "spec.behavior.scaleDown.stabilizationWindowSeconds",
+		},
+		"valid scaling policies": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Behavior = &bigtablev2.BigtableAutoscalerBehavior{
+					ScaleUp: &bigtablev2.ScalingRules{
+						Policies: []bigtablev2.ScalingPolicy{
+							{Type: bigtablev2.NodesScalingPolicy, Value: 4, PeriodSeconds: 60},
+							{Type: bigtablev2.PercentScalingPolicy, Value: 100, PeriodSeconds: 60},
+						},
+					},
+				}
+			},
+		},
+		"scaling policy with zero value": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Behavior = &bigtablev2.BigtableAutoscalerBehavior{
+					ScaleUp: &bigtablev2.ScalingRules{
+						Policies: []bigtablev2.ScalingPolicy{{Type: bigtablev2.NodesScalingPolicy, Value: 0, PeriodSeconds: 60}},
+					},
+				}
+			},
+			expectedField: "spec.behavior.scaleUp.policies[0].value",
+		},
+		"scaling policy period above max": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Behavior = &bigtablev2.BigtableAutoscalerBehavior{
+					ScaleDown: &bigtablev2.ScalingRules{
+						Policies: []bigtablev2.ScalingPolicy{{Type: bigtablev2.PercentScalingPolicy, Value: 10, PeriodSeconds: 1801}},
+					},
+				}
+			},
+			expectedField: "spec.behavior.scaleDown.policies[0].periodSeconds",
+		},
+		"unknown select policy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				selectPolicy := bigtablev2.ScalingPolicySelect("Average")
+				autoscaler.Spec.Behavior = &bigtablev2.BigtableAutoscalerBehavior{
+					ScaleUp: &bigtablev2.ScalingRules{SelectPolicy: &selectPolicy},
+				}
+			},
+			expectedField: "spec.behavior.scaleUp.selectPolicy",
+		},
+		"recommend mode": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Mode = bigtablev2.RecommendAutoscalerMode
+			},
+		},
+		"unknown mode": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Mode = "Shadow"
+			},
+			expectedField: "spec.mode",
+		},
+		"unknown strategy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = "Quadratic"
+			},
+			expectedField: "spec.strategy",
+		},
+		"pid strategy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.PIDScalingStrategy
+				autoscaler.Spec.PID = &bigtablev2.PIDStrategy{DerivativeSmoothing: pointer.Int32(80)}
+			},
+		},
+		"pid without the pid strategy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.PID = &bigtablev2.PIDStrategy{}
+			},
+			expectedField: "spec.pid",
+		},
+		"negative pid gain": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				gain := resource.MustParse("-0.5")
+				autoscaler.Spec.Strategy = bigtablev2.PIDScalingStrategy
+				autoscaler.Spec.PID = &bigtablev2.PIDStrategy{IntegralGain: &gain}
+			},
+			expectedField: "spec.pid.integralGain",
+		},
+		"pid derivative smoothing out of range": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.PIDScalingStrategy
+				autoscaler.Spec.PID = &bigtablev2.PIDStrategy{DerivativeSmoothing: pointer.Int32(100)}
+			},
+			expectedField: "spec.pid.derivativeSmoothing",
+		},
+		"steps strategy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.StepsScalingStrategy
+				autoscaler.Spec.Steps = []bigtablev2.ScalingStep{{Threshold: 150, Change: 2}, {Threshold: 50, Change: -1}}
+			},
+		},
+		"steps strategy without steps": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.StepsScalingStrategy
+			},
+			expectedField: "spec.steps",
+		},
+		"steps without the steps strategy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Steps = []bigtablev2.ScalingStep{{Threshold: 150, Change: 2}}
+			},
+			expectedField: "spec.steps",
+		},
+		"step at the target": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.StepsScalingStrategy
+				autoscaler.Spec.Steps = []bigtablev2.ScalingStep{{Threshold: 100, Change: 2}}
+			},
+			expectedField: "spec.steps[0].threshold",
+		},
+		"step removing nodes above the target": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.StepsScalingStrategy
+				autoscaler.Spec.Steps = []bigtablev2.ScalingStep{{Threshold: 150, Change: -2}}
+			},
+			expectedField: "spec.steps[0].change",
+		},
+		"predictive strategy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.PredictiveScalingStrategy
+				autoscaler.Spec.Predictive = &bigtablev2.PredictiveStrategy{Horizon: &metav1.Duration{Duration: 10 * time.Minute}}
+			},
+		},
+		"predictive without the predictive strategy": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Predictive = &bigtablev2.PredictiveStrategy{}
+			},
+			expectedField: "spec.predictive",
+		},
+		"predictive horizon too far": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.Strategy = bigtablev2.PredictiveScalingStrategy
+				autoscaler.Spec.Predictive = &bigtablev2.PredictiveStrategy{Horizon: &metav1.Duration{Duration: time.Hour}}
+			},
+			expectedField: "spec.predictive.horizon",
+		},
+		"valid on delete scale to": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.OnDelete = &bigtablev2.OnDeletePolicy{Action: bigtablev2.ScaleToOnDeleteAction, Nodes: pointer.Int32(3)}
+			},
+		},
+		"on delete scale to without nodes": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.OnDelete = &bigtablev2.OnDeletePolicy{Action: bigtablev2.ScaleToOnDeleteAction}
+			},
+			expectedField: "spec.onDelete.nodes",
+		},
+		"on delete scale to min with nodes": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.OnDelete = &bigtablev2.OnDeletePolicy{Action: bigtablev2.ScaleToMinOnDeleteAction, Nodes: pointer.Int32(3)}
+			},
+			expectedField: "spec.onDelete.nodes",
+		},
+		"unknown on delete action": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.OnDelete = &bigtablev2.OnDeletePolicy{Action: "Delete"}
+			},
+			expectedField: "spec.onDelete.action",
+		},
+		"empty project id": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.BigtableClusterRef.ProjectID = ""
+			},
+			expectedField: "spec.bigtableClusterRef.projectId",
+		},
+		"empty instance id": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.BigtableClusterRef.InstanceID = ""
+			},
+			expectedField: "spec.bigtableClusterRef.instanceId",
+		},
+		"empty cluster id": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.BigtableClusterRef.ClusterID = ""
+			},
+			expectedField: "spec.bigtableClusterRef.clusterId",
+		},
+		"no secret uses application default credentials": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ServiceAccountSecretRef = nil
+			},
+		},
+		"valid impersonation": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ImpersonateServiceAccount = "autoscaler@cool-project.iam.gserviceaccount.com"
+				autoscaler.Spec.ImpersonationDelegates = []string{"delegate@cool-project.iam.gserviceaccount.com"}
+			},
+		},
+		"impersonated service account is not an email": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ImpersonateServiceAccount = "autoscaler"
+			},
+			expectedField: "spec.impersonateServiceAccount",
+		},
+		"delegates without impersonated service account": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ImpersonationDelegates = []string{"delegate@cool-project.iam.gserviceaccount.com"}
+			},
+			expectedField: "spec.impersonationDelegates",
+		},
+		"missing secret name": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ServiceAccountSecretRef.Name = nil
+			},
+			expectedField: "spec.serviceAccountSecretRef.name",
+		},
+		"empty secret key": {
+			mutate: func(autoscaler *bigtablev2.BigtableAutoscaler) {
+				autoscaler.Spec.ServiceAccountSecretRef.Key = pointer.String("")
+			},
+			expectedField: "spec.serviceAccountSecretRef.key",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			autoscaler := validAutoscaler()
+			test.mutate(autoscaler)
+
+			err := autoscaler.ValidateCreate()
+
+			if test.expectedField == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.True(t, apierrors.IsInvalid(err))
+				assert.Contains(t, err.Error(), test.expectedField)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BigtableCapacityReservationSpec defines the desired state of BigtableCapacityReservation
+type BigtableCapacityReservationSpec struct {
+	// autoscaler of the same namespace whose floor is raised.
+	AutoscalerRef corev1.LocalObjectReference `json:"autoscalerRef"`
+
+	// +kubebuilder:validation:Minimum=1
+	// number of nodes the cluster is kept at least at between startTime and endTime.
+	Nodes int32 `json:"nodes"`
+
+	// when the reservation starts.
+	StartTime metav1.Time `json:"startTime"`
+
+	// when the reservation ends. It is deleted afterwards.
+	EndTime metav1.Time `json:"endTime"`
+
+	// +optional
+	// how long before startTime the floor starts rising from minNodes, so that the nodes
+	// are ready by then. The floor rises linearly until it reaches nodes at startTime.
+	RampUpLeadTime *metav1.Duration `json:"rampUpLeadTime,omitempty"`
+}
+
+// CapacityReservationPhase is the stage of a reservation.
+type CapacityReservationPhase string
+
+const (
+	// PendingCapacityReservationPhase is a reservation that didn't start yet.
+	PendingCapacityReservationPhase CapacityReservationPhase = "Pending"
+
+	// RampingUpCapacityReservationPhase is a reservation within its ramp up lead time.
+	RampingUpCapacityReservationPhase CapacityReservationPhase = "RampingUp"
+
+	// ActiveCapacityReservationPhase is a reservation between its start and end times.
+	ActiveCapacityReservationPhase CapacityReservationPhase = "Active"
+)
+
+// BigtableCapacityReservationStatus defines the observed state of BigtableCapacityReservation
+type BigtableCapacityReservationStatus struct {
+	// +optional
+	// stage of the reservation: Pending, RampingUp or Active.
+	Phase CapacityReservationPhase `json:"phase,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="autoscaler",type=string,JSONPath=`.spec.autoscalerRef.name`
+// +kubebuilder:printcolumn:name="nodes",type=string,JSONPath=`.spec.nodes`
+// +kubebuilder:printcolumn:name="start",type=string,JSONPath=`.spec.startTime`
+// +kubebuilder:printcolumn:name="end",type=string,JSONPath=`.spec.endTime`
+// +kubebuilder:printcolumn:name="phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:subresource:status
+
+// BigtableCapacityReservation is the Schema for the bigtablecapacityreservations API. It raises
+// the floor of an autoscaler for a known one-time event, e.g. a backfill or a migration.
+type BigtableCapacityReservation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BigtableCapacityReservationSpec   `json:"spec,omitempty"`
+	Status BigtableCapacityReservationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BigtableCapacityReservationList contains a list of BigtableCapacityReservation
+type BigtableCapacityReservationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BigtableCapacityReservation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BigtableCapacityReservation{}, &BigtableCapacityReservationList{})
+}
@@ -0,0 +1,82 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *BigtableCapacityReservation) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-bigtable-bigtable-autoscaler-com-v2-bigtablecapacityreservation,mutating=false,failurePolicy=fail,sideEffects=None,groups=bigtable.bigtable-autoscaler.com,resources=bigtablecapacityreservations,verbs=create;update,versions=v2,name=vbigtablecapacityreservation.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &BigtableCapacityReservation{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *BigtableCapacityReservation) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *BigtableCapacityReservation) ValidateUpdate(old runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *BigtableCapacityReservation) ValidateDelete() error {
+	return nil
+}
+
+func (r *BigtableCapacityReservation) validate() error {
+	allErrs := r.Spec.validate(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("BigtableCapacityReservation").GroupKind(), r.Name, allErrs)
+}
+
+func (s *BigtableCapacityReservationSpec) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if s.AutoscalerRef.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("autoscalerRef", "name"), "must not be empty"))
+	}
+
+	if s.Nodes < 1 {
+		allErrs = append(allErrs, field.Invalid(path.Child("nodes"), s.Nodes, "must be greater than zero"))
+	}
+
+	if !s.EndTime.After(s.StartTime.Time) {
+		allErrs = append(allErrs, field.Invalid(path.Child("endTime"), s.EndTime, "must be after startTime"))
+	}
+
+	if s.RampUpLeadTime != nil && s.RampUpLeadTime.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("rampUpLeadTime"), s.RampUpLeadTime.Duration.String(),
+			"must not be negative"))
+	}
+
+	return allErrs
+}
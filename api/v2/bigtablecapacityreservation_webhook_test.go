@@ -0,0 +1,103 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+func validCapacityReservation() *bigtablev2.BigtableCapacityReservation {
+	start := time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC)
+
+	return &bigtablev2.BigtableCapacityReservation{
+		Spec: bigtablev2.BigtableCapacityReservationSpec{
+			AutoscalerRef:  corev1.LocalObjectReference{Name: "my-autoscaler"},
+			Nodes:          12,
+			StartTime:      metav1.NewTime(start),
+			EndTime:        metav1.NewTime(start.Add(24 * time.Hour)),
+			RampUpLeadTime: &metav1.Duration{Duration: time.Hour},
+		},
+	}
+}
+
+func TestValidateCreateCapacityReservation(t *testing.T) {
+	tests := map[string]struct {
+		mutate        func(reservation *bigtablev2.BigtableCapacityReservation)
+		expectedField string
+	}{
+		"valid reservation": {
+			mutate: func(reservation *bigtablev2.BigtableCapacityReservation) {},
+		},
+		"without ramp up": {
+			mutate: func(reservation *bigtablev2.BigtableCapacityReservation) {
+				reservation.Spec.RampUpLeadTime = nil
+			},
+		},
+		"missing autoscaler": {
+			mutate: func(reservation *bigtablev2.BigtableCapacityReservation) {
+				reservation.Spec.AutoscalerRef.Name = ""
+			},
+			expectedField: "spec.autoscalerRef.name",
+		},
+		"zero nodes": {
+			mutate: func(reservation *bigtablev2.BigtableCapacityReservation) {
+				reservation.Spec.Nodes = 0
+			},
+			expectedField: "spec.nodes",
+		},
+		"end before start": {
+			mutate: func(reservation *bigtablev2.BigtableCapacityReservation) {
+				reservation.Spec.EndTime = metav1.NewTime(reservation.Spec.StartTime.Add(-time.Hour))
+			},
+			expectedField: "spec.endTime",
+		},
+		"negative ramp up lead time": {
+			mutate: func(reservation *bigtablev2.BigtableCapacityReservation) {
+				reservation.Spec.RampUpLeadTime = &metav1.Duration{Duration: -time.Hour}
+			},
+			expectedField: "spec.rampUpLeadTime",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			reservation := validCapacityReservation()
+			test.mutate(reservation)
+
+			err := reservation.ValidateCreate()
+
+			if test.expectedField == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.True(t, apierrors.IsInvalid(err))
+				assert.Contains(t, err.Error(), test.expectedField)
+			}
+		})
+	}
+}
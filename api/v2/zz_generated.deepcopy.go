@@ -0,0 +1,889 @@
+// +build !ignore_autogenerated
+
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableAutoscaler) DeepCopyInto(out *BigtableAutoscaler) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableAutoscaler.
+func (in *BigtableAutoscaler) DeepCopy() *BigtableAutoscaler {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableAutoscaler)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BigtableAutoscaler) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableAutoscalerBehavior) DeepCopyInto(out *BigtableAutoscalerBehavior) {
+	*out = *in
+	if in.ScaleUp != nil {
+		in, out := &in.ScaleUp, &out.ScaleUp
+		*out = new(ScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleDown != nil {
+		in, out := &in.ScaleDown, &out.ScaleDown
+		*out = new(ScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableAutoscalerBehavior.
+func (in *BigtableAutoscalerBehavior) DeepCopy() *BigtableAutoscalerBehavior {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableAutoscalerBehavior)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableAutoscalerList) DeepCopyInto(out *BigtableAutoscalerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BigtableAutoscaler, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableAutoscalerList.
+func (in *BigtableAutoscalerList) DeepCopy() *BigtableAutoscalerList {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableAutoscalerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BigtableAutoscalerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableAutoscalerSpec) DeepCopyInto(out *BigtableAutoscalerSpec) {
+	*out = *in
+	if in.MinNodes != nil {
+		in, out := &in.MinNodes, &out.MinNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxNodes != nil {
+		in, out := &in.MaxNodes, &out.MaxNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxScaleDownNodes != nil {
+		in, out := &in.MaxScaleDownNodes, &out.MaxScaleDownNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]MetricSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricWindow != nil {
+		in, out := &in.MetricWindow, &out.MetricWindow
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.AlignmentPeriod != nil {
+		in, out := &in.AlignmentPeriod, &out.AlignmentPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Behavior != nil {
+		in, out := &in.Behavior, &out.Behavior
+		*out = new(BigtableAutoscalerBehavior)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PID != nil {
+		in, out := &in.PID, &out.PID
+		*out = new(PIDStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]ScalingStep, len(*in))
+		copy(*out, *in)
+	}
+	if in.Predictive != nil {
+		in, out := &in.Predictive, &out.Predictive
+		*out = new(PredictiveStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ManualOverrideGracePeriod != nil {
+		in, out := &in.ManualOverrideGracePeriod, &out.ManualOverrideGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]Schedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScaleDownBlackouts != nil {
+		in, out := &in.ScaleDownBlackouts, &out.ScaleDownBlackouts
+		*out = make([]ScaleDownBlackout, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OnDelete != nil {
+		in, out := &in.OnDelete, &out.OnDelete
+		*out = new(OnDeletePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	out.BigtableClusterRef = in.BigtableClusterRef
+	if in.ServiceAccountSecretRef != nil {
+		in, out := &in.ServiceAccountSecretRef, &out.ServiceAccountSecretRef
+		*out = new(ServiceAccountSecretRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImpersonationDelegates != nil {
+		in, out := &in.ImpersonationDelegates, &out.ImpersonationDelegates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableAutoscalerSpec.
+func (in *BigtableAutoscalerSpec) DeepCopy() *BigtableAutoscalerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableAutoscalerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableAutoscalerStatus) DeepCopyInto(out *BigtableAutoscalerStatus) {
+	*out = *in
+	if in.LastScaleTime != nil {
+		in, out := &in.LastScaleTime, &out.LastScaleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFetchTime != nil {
+		in, out := &in.LastFetchTime, &out.LastFetchTime
+		*out = (*in).DeepCopy()
+	}
+	if in.DesiredNodes != nil {
+		in, out := &in.DesiredNodes, &out.DesiredNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CurrentNodes != nil {
+		in, out := &in.CurrentNodes, &out.CurrentNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CurrentMetrics != nil {
+		in, out := &in.CurrentMetrics, &out.CurrentMetrics
+		*out = make([]MetricStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CurrentStorageUtilization != nil {
+		in, out := &in.CurrentStorageUtilization, &out.CurrentStorageUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EffectiveMinNodes != nil {
+		in, out := &in.EffectiveMinNodes, &out.EffectiveMinNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Recommendations != nil {
+		in, out := &in.Recommendations, &out.Recommendations
+		*out = make([]Recommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Samples != nil {
+		in, out := &in.Samples, &out.Samples
+		*out = make([]MetricSample, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScaleEvents != nil {
+		in, out := &in.ScaleEvents, &out.ScaleEvents
+		*out = make([]ScaleEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScaleUpRecommendation != nil {
+		in, out := &in.ScaleUpRecommendation, &out.ScaleUpRecommendation
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleDownRecommendation != nil {
+		in, out := &in.ScaleDownRecommendation, &out.ScaleDownRecommendation
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RecommendedNodes != nil {
+		in, out := &in.RecommendedNodes, &out.RecommendedNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReservedNodes != nil {
+		in, out := &in.ReservedNodes, &out.ReservedNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LastAppliedNodes != nil {
+		in, out := &in.LastAppliedNodes, &out.LastAppliedNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ManualOverride != nil {
+		in, out := &in.ManualOverride, &out.ManualOverride
+		*out = new(ManualOverride)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PID != nil {
+		in, out := &in.PID, &out.PID
+		*out = new(PIDStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableAutoscalerStatus.
+func (in *BigtableAutoscalerStatus) DeepCopy() *BigtableAutoscalerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableAutoscalerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableCapacityReservation) DeepCopyInto(out *BigtableCapacityReservation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableCapacityReservation.
+func (in *BigtableCapacityReservation) DeepCopy() *BigtableCapacityReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableCapacityReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BigtableCapacityReservation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableCapacityReservationList) DeepCopyInto(out *BigtableCapacityReservationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BigtableCapacityReservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableCapacityReservationList.
+func (in *BigtableCapacityReservationList) DeepCopy() *BigtableCapacityReservationList {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableCapacityReservationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BigtableCapacityReservationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableCapacityReservationSpec) DeepCopyInto(out *BigtableCapacityReservationSpec) {
+	*out = *in
+	out.AutoscalerRef = in.AutoscalerRef
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.EndTime.DeepCopyInto(&out.EndTime)
+	if in.RampUpLeadTime != nil {
+		in, out := &in.RampUpLeadTime, &out.RampUpLeadTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableCapacityReservationSpec.
+func (in *BigtableCapacityReservationSpec) DeepCopy() *BigtableCapacityReservationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableCapacityReservationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableCapacityReservationStatus) DeepCopyInto(out *BigtableCapacityReservationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableCapacityReservationStatus.
+func (in *BigtableCapacityReservationStatus) DeepCopy() *BigtableCapacityReservationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableCapacityReservationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BigtableClusterRef) DeepCopyInto(out *BigtableClusterRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BigtableClusterRef.
+func (in *BigtableClusterRef) DeepCopy() *BigtableClusterRef {
+	if in == nil {
+		return nil
+	}
+	out := new(BigtableClusterRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricSource) DeepCopyInto(out *ExternalMetricSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricSource.
+func (in *ExternalMetricSource) DeepCopy() *ExternalMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManualOverride) DeepCopyInto(out *ManualOverride) {
+	*out = *in
+	in.DetectedTime.DeepCopyInto(&out.DetectedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManualOverride.
+func (in *ManualOverride) DeepCopy() *ManualOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ManualOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricSample) DeepCopyInto(out *MetricSample) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]MetricStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSample.
+func (in *MetricSample) DeepCopy() *MetricSample {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricSample)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
+	*out = *in
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalMetricSource)
+		**out = **in
+	}
+	in.Target.DeepCopyInto(&out.Target)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSpec.
+func (in *MetricSpec) DeepCopy() *MetricSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricStatus) DeepCopyInto(out *MetricStatus) {
+	*out = *in
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalMetricSource)
+		**out = **in
+	}
+	in.Current.DeepCopyInto(&out.Current)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricStatus.
+func (in *MetricStatus) DeepCopy() *MetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricTarget) DeepCopyInto(out *MetricTarget) {
+	*out = *in
+	if in.AverageUtilization != nil {
+		in, out := &in.AverageUtilization, &out.AverageUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.AverageValue != nil {
+		in, out := &in.AverageValue, &out.AverageValue
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricTarget.
+func (in *MetricTarget) DeepCopy() *MetricTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricValueStatus) DeepCopyInto(out *MetricValueStatus) {
+	*out = *in
+	if in.AverageUtilization != nil {
+		in, out := &in.AverageUtilization, &out.AverageUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.AverageValue != nil {
+		in, out := &in.AverageValue, &out.AverageValue
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricValueStatus.
+func (in *MetricValueStatus) DeepCopy() *MetricValueStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricValueStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnDeletePolicy) DeepCopyInto(out *OnDeletePolicy) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnDeletePolicy.
+func (in *OnDeletePolicy) DeepCopy() *OnDeletePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OnDeletePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PIDStatus) DeepCopyInto(out *PIDStatus) {
+	*out = *in
+	out.Integral = in.Integral.DeepCopy()
+	out.Derivative = in.Derivative.DeepCopy()
+	out.LastError = in.LastError.DeepCopy()
+	in.LastSampleTime.DeepCopyInto(&out.LastSampleTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PIDStatus.
+func (in *PIDStatus) DeepCopy() *PIDStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PIDStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PIDStrategy) DeepCopyInto(out *PIDStrategy) {
+	*out = *in
+	if in.ProportionalGain != nil {
+		in, out := &in.ProportionalGain, &out.ProportionalGain
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.IntegralGain != nil {
+		in, out := &in.IntegralGain, &out.IntegralGain
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.DerivativeGain != nil {
+		in, out := &in.DerivativeGain, &out.DerivativeGain
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.IntegralLimit != nil {
+		in, out := &in.IntegralLimit, &out.IntegralLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.DerivativeSmoothing != nil {
+		in, out := &in.DerivativeSmoothing, &out.DerivativeSmoothing
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PIDStrategy.
+func (in *PIDStrategy) DeepCopy() *PIDStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(PIDStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PredictiveStrategy) DeepCopyInto(out *PredictiveStrategy) {
+	*out = *in
+	if in.Horizon != nil {
+		in, out := &in.Horizon, &out.Horizon
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PredictiveStrategy.
+func (in *PredictiveStrategy) DeepCopy() *PredictiveStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(PredictiveStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Recommendation) DeepCopyInto(out *Recommendation) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recommendation.
+func (in *Recommendation) DeepCopy() *Recommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(Recommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleDownBlackout) DeepCopyInto(out *ScaleDownBlackout) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleDownBlackout.
+func (in *ScaleDownBlackout) DeepCopy() *ScaleDownBlackout {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleDownBlackout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleEvent) DeepCopyInto(out *ScaleEvent) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleEvent.
+func (in *ScaleEvent) DeepCopy() *ScaleEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPolicy) DeepCopyInto(out *ScalingPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPolicy.
+func (in *ScalingPolicy) DeepCopy() *ScalingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingRules) DeepCopyInto(out *ScalingRules) {
+	*out = *in
+	if in.StabilizationWindowSeconds != nil {
+		in, out := &in.StabilizationWindowSeconds, &out.StabilizationWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SelectPolicy != nil {
+		in, out := &in.SelectPolicy, &out.SelectPolicy
+		*out = new(ScalingPolicySelect)
+		**out = **in
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]ScalingPolicy, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingRules.
+func (in *ScalingRules) DeepCopy() *ScalingRules {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingStep) DeepCopyInto(out *ScalingStep) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStep.
+func (in *ScalingStep) DeepCopy() *ScalingStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Schedule) DeepCopyInto(out *Schedule) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.MinNodes != nil {
+		in, out := &in.MinNodes, &out.MinNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxNodes != nil {
+		in, out := &in.MaxNodes, &out.MaxNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilization != nil {
+		in, out := &in.TargetCPUUtilization, &out.TargetCPUUtilization
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Schedule.
+func (in *Schedule) DeepCopy() *Schedule {
+	if in == nil {
+		return nil
+	}
+	out := new(Schedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountSecretRef) DeepCopyInto(out *ServiceAccountSecretRef) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountSecretRef.
+func (in *ServiceAccountSecretRef) DeepCopy() *ServiceAccountSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
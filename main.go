@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
 	"bigtable-autoscaler.com/m/v2/pkg/controllers"
 	// +kubebuilder:scaffold:imports
 )
@@ -40,16 +41,21 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = bigtablev1.AddToScheme(scheme)
+	_ = bigtablev2.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var dryRun bool
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Run every autoscaler in Recommend mode: the desired number of nodes is recorded in status and events, "+
+			"but clusters are never resized.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -66,12 +72,35 @@ func main() {
 		os.Exit(1)
 	}
 
-	r := controllers.NewBigtableReconciler(mgr.GetClient(), mgr.GetAPIReader(), mgr.GetScheme())
+	r := controllers.NewBigtableReconciler(
+		mgr.GetClient(),
+		mgr.GetAPIReader(),
+		mgr.GetScheme(),
+		mgr.GetEventRecorderFor("bigtable-autoscaler"),
+		dryRun,
+	)
 
 	if err = r.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BigtableAutoscaler")
 		os.Exit(1)
 	}
+
+	if err = controllers.NewBigtableCapacityReservationReconciler(mgr.GetClient()).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BigtableCapacityReservation")
+		os.Exit(1)
+	}
+
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&bigtablev2.BigtableAutoscaler{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BigtableAutoscaler")
+			os.Exit(1)
+		}
+
+		if err = (&bigtablev2.BigtableCapacityReservation{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BigtableCapacityReservation")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
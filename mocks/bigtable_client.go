@@ -14,6 +14,20 @@ type BigtableClient struct {
 	mock.Mock
 }
 
+// Close provides a mock function with given fields:
+func (_m *BigtableClient) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Clusters provides a mock function with given fields: ctx, instanceID
 func (_m *BigtableClient) Clusters(ctx context.Context, instanceID string) ([]googlecloud.ClusterInfo, error) {
 	ret := _m.Called(ctx, instanceID)
@@ -36,3 +50,17 @@ func (_m *BigtableClient) Clusters(ctx context.Context, instanceID string) ([]go
 
 	return r0, r1
 }
+
+// UpdateCluster provides a mock function with given fields: ctx, instanceID, clusterID, serveNodes
+func (_m *BigtableClient) UpdateCluster(ctx context.Context, instanceID string, clusterID string, serveNodes int32) error {
+	ret := _m.Called(ctx, instanceID, clusterID, serveNodes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int32) error); ok {
+		r0 = rf(ctx, instanceID, clusterID, serveNodes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
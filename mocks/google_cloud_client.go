@@ -2,27 +2,72 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	googlecloud "bigtable-autoscaler.com/m/v2/pkg/googlecloud"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // GoogleCloudClient is an autogenerated mock type for the GoogleCloudClient type
 type GoogleCloudClient struct {
 	mock.Mock
 }
 
-// GetCurrentCPULoad provides a mock function with given fields:
-func (_m *GoogleCloudClient) GetCurrentCPULoad() (int32, error) {
-	ret := _m.Called()
+// GetCurrentCPULoad provides a mock function with given fields: opts
+func (_m *GoogleCloudClient) GetCurrentCPULoad(opts googlecloud.QueryOptions) (int32, error) {
+	ret := _m.Called(opts)
 
 	var r0 int32
-	if rf, ok := ret.Get(0).(func() int32); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(googlecloud.QueryOptions) int32); ok {
+		r0 = rf(opts)
 	} else {
 		r0 = ret.Get(0).(int32)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(googlecloud.QueryOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCurrentHottestNodeCPULoad provides a mock function with given fields: opts
+func (_m *GoogleCloudClient) GetCurrentHottestNodeCPULoad(opts googlecloud.QueryOptions) (int32, error) {
+	ret := _m.Called(opts)
+
+	var r0 int32
+	if rf, ok := ret.Get(0).(func(googlecloud.QueryOptions) int32); ok {
+		r0 = rf(opts)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(googlecloud.QueryOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCurrentMetricValue provides a mock function with given fields: metricType, filter, opts
+func (_m *GoogleCloudClient) GetCurrentMetricValue(metricType string, filter string, opts googlecloud.QueryOptions) (float64, error) {
+	ret := _m.Called(metricType, filter, opts)
+
+	var r0 float64
+	if rf, ok := ret.Get(0).(func(string, string, googlecloud.QueryOptions) float64); ok {
+		r0 = rf(metricType, filter, opts)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, googlecloud.QueryOptions) error); ok {
+		r1 = rf(metricType, filter, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -50,3 +95,38 @@ func (_m *GoogleCloudClient) GetCurrentNodeCount(clusterID string) (int32, error
 
 	return r0, r1
 }
+
+// GetCurrentStorageUtilization provides a mock function with given fields: opts
+func (_m *GoogleCloudClient) GetCurrentStorageUtilization(opts googlecloud.QueryOptions) (int32, error) {
+	ret := _m.Called(opts)
+
+	var r0 int32
+	if rf, ok := ret.Get(0).(func(googlecloud.QueryOptions) int32); ok {
+		r0 = rf(opts)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(googlecloud.QueryOptions) error); ok {
+		r1 = rf(opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateNodeCount provides a mock function with given fields: clusterID, nodes
+func (_m *GoogleCloudClient) UpdateNodeCount(clusterID string, nodes int32) error {
+	ret := _m.Called(clusterID, nodes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int32) error); ok {
+		r0 = rf(clusterID, nodes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
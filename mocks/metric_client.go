@@ -16,6 +16,20 @@ type MetricClient struct {
 	mock.Mock
 }
 
+// Close provides a mock function with given fields:
+func (_m *MetricClient) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ListTimeSeries provides a mock function with given fields: ctx, req
 func (_m *MetricClient) ListTimeSeries(ctx context.Context, req *monitoring.ListTimeSeriesRequest) googlecloud.TimeSeriesIterator {
 	ret := _m.Called(ctx, req)
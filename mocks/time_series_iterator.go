@@ -9,16 +9,16 @@ type TimeSeriesIterator struct {
 	mock.Mock
 }
 
-// Points provides a mock function with given fields:
-func (_m *TimeSeriesIterator) Points() ([]int32, error) {
+// Values provides a mock function with given fields:
+func (_m *TimeSeriesIterator) Values() ([]float64, error) {
 	ret := _m.Called()
 
-	var r0 []int32
-	if rf, ok := ret.Get(0).(func() []int32); ok {
+	var r0 []float64
+	if rf, ok := ret.Get(0).(func() []float64); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]int32)
+			r0 = ret.Get(0).([]float64)
 		}
 	}
 
@@ -0,0 +1,105 @@
+package conditions
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// readyDependencies are the conditions that must be True for the autoscaler to be Ready.
+var readyDependencies = []string{
+	bigtablev2.ConditionCredentialsValid,
+	bigtablev2.ConditionMetricsAvailable,
+	bigtablev2.ConditionScalingActive,
+}
+
+// Set adds or updates the condition of the same type. LastTransitionTime is
+// only changed when the status changes, and defaults to now when unset.
+func Set(conditions *[]bigtablev2.Condition, newCondition bigtablev2.Condition) {
+	if newCondition.LastTransitionTime.IsZero() {
+		newCondition.LastTransitionTime = metav1.NewTime(time.Now())
+	}
+
+	existing := Find(*conditions, newCondition.Type)
+	if existing == nil {
+		*conditions = append(*conditions, newCondition)
+
+		return
+	}
+
+	if existing.Status != newCondition.Status {
+		existing.Status = newCondition.Status
+		existing.LastTransitionTime = newCondition.LastTransitionTime
+	}
+
+	existing.Reason = newCondition.Reason
+	existing.Message = newCondition.Message
+	existing.ObservedGeneration = newCondition.ObservedGeneration
+}
+
+// Find returns the condition of the given type, or nil when it is not present.
+func Find(conditions []bigtablev2.Condition, conditionType string) *bigtablev2.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// IsTrue tells whether the condition of the given type is present and True.
+func IsTrue(conditions []bigtablev2.Condition, conditionType string) bool {
+	condition := Find(conditions, conditionType)
+
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
+
+// IsFalse tells whether the condition of the given type is present and False.
+func IsFalse(conditions []bigtablev2.Condition, conditionType string) bool {
+	condition := Find(conditions, conditionType)
+
+	return condition != nil && condition.Status == metav1.ConditionFalse
+}
+
+// SetReady recomputes the Ready condition from the conditions it depends on.
+// A False dependency makes Ready False with the same reason; a missing or
+// Unknown dependency makes it Unknown.
+func SetReady(conditions *[]bigtablev2.Condition, generation int64) {
+	ready := bigtablev2.Condition{
+		Type:               bigtablev2.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "AutoscalerReady",
+		Message:            "the autoscaler is able to fetch metrics and scale the cluster",
+	}
+
+	for _, conditionType := range readyDependencies {
+		condition := Find(*conditions, conditionType)
+
+		if condition == nil || condition.Status == metav1.ConditionUnknown {
+			if ready.Status == metav1.ConditionUnknown {
+				continue
+			}
+
+			ready.Status = metav1.ConditionUnknown
+			ready.Reason = conditionType + "Unknown"
+			ready.Message = fmt.Sprintf("condition %s has not been observed yet", conditionType)
+
+			continue
+		}
+
+		if condition.Status == metav1.ConditionFalse {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = condition.Reason
+			ready.Message = condition.Message
+
+			break
+		}
+	}
+
+	Set(conditions, ready)
+}
@@ -0,0 +1,101 @@
+package conditions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/conditions"
+)
+
+func TestSet(t *testing.T) {
+	before := metav1.NewTime(time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC))
+	after := metav1.NewTime(before.Add(time.Minute))
+
+	tests := map[string]struct {
+		status             metav1.ConditionStatus
+		expectedTransition metav1.Time
+	}{
+		"keeps transition time when status is unchanged": {status: metav1.ConditionTrue, expectedTransition: before},
+		"updates transition time when status changes":    {status: metav1.ConditionFalse, expectedTransition: after},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			current := []bigtablev2.Condition{
+				{
+					Type:               bigtablev2.ConditionMetricsAvailable,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: before,
+					Reason:             "OldReason",
+				},
+			}
+
+			conditions.Set(&current, bigtablev2.Condition{
+				Type:               bigtablev2.ConditionMetricsAvailable,
+				Status:             test.status,
+				LastTransitionTime: after,
+				Reason:             "NewReason",
+			})
+
+			assert.Len(t, current, 1)
+			assert.Equal(t, test.status, current[0].Status)
+			assert.Equal(t, "NewReason", current[0].Reason)
+			assert.True(t, test.expectedTransition.Equal(&current[0].LastTransitionTime))
+		})
+	}
+}
+
+func TestSetReady(t *testing.T) {
+	condition := func(conditionType string, status metav1.ConditionStatus, reason string) bigtablev2.Condition {
+		return bigtablev2.Condition{Type: conditionType, Status: status, Reason: reason}
+	}
+
+	tests := map[string]struct {
+		conditions     []bigtablev2.Condition
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		"all dependencies true": {
+			conditions: []bigtablev2.Condition{
+				condition(bigtablev2.ConditionCredentialsValid, metav1.ConditionTrue, "Valid"),
+				condition(bigtablev2.ConditionMetricsAvailable, metav1.ConditionTrue, "Fetched"),
+				condition(bigtablev2.ConditionScalingActive, metav1.ConditionTrue, "Active"),
+			},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "AutoscalerReady",
+		},
+		"a dependency is false": {
+			conditions: []bigtablev2.Condition{
+				condition(bigtablev2.ConditionCredentialsValid, metav1.ConditionTrue, "Valid"),
+				condition(bigtablev2.ConditionMetricsAvailable, metav1.ConditionFalse, "FailedGetCPULoad"),
+				condition(bigtablev2.ConditionScalingActive, metav1.ConditionTrue, "Active"),
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "FailedGetCPULoad",
+		},
+		"a dependency is missing": {
+			conditions: []bigtablev2.Condition{
+				condition(bigtablev2.ConditionCredentialsValid, metav1.ConditionTrue, "Valid"),
+			},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "MetricsAvailableUnknown",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			conditions.SetReady(&test.conditions, 1)
+
+			ready := conditions.Find(test.conditions, bigtablev2.ConditionReady)
+			if assert.NotNil(t, ready) {
+				assert.Equal(t, test.expectedStatus, ready.Status)
+				assert.Equal(t, test.expectedReason, ready.Reason)
+				assert.Equal(t, int64(1), ready.ObservedGeneration)
+			}
+		})
+	}
+}
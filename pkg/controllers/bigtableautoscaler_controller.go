@@ -18,23 +18,31 @@ package controllers
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
-	"google.golang.org/api/option"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	"cloud.google.com/go/bigtable"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/conditions"
 	"bigtable-autoscaler.com/m/v2/pkg/googlecloud"
 	"bigtable-autoscaler.com/m/v2/pkg/nodes_calculator"
 	"bigtable-autoscaler.com/m/v2/pkg/status"
@@ -42,54 +50,187 @@ import (
 
 const optimisticLockErrorMsg = "the object has been modified; please apply your changes to the latest version and try again"
 
+// finalizerName keeps a deleted autoscaler around until its sync routine is stopped and its
+// onDelete policy is run.
+const finalizerName = "bigtable.bigtable-autoscaler.com/finalizer"
+
+// Bounds of the delay before retrying an autoscaler whose credentials are invalid.
+const (
+	minCredentialsBackoff = 5 * time.Second
+	maxCredentialsBackoff = 5 * time.Minute
+)
+
+// onDeleteTimeout is how long the onDelete policy of a deleted autoscaler is retried before its
+// finalizer is removed anyway, so that a cluster that can't be reached doesn't block the deletion,
+// e.g. of the namespace, forever.
+const onDeleteTimeout = 5 * time.Minute
+
 // BigtableAutoscalerReconciler reconciles a BigtableAutoscaler object
 type BigtableAutoscalerReconciler struct {
 	ctrlclient.Client
 
-	reader ctrlclient.Reader
-	scheme *runtime.Scheme
-	syncer *status.Syncer
-	clock  clock.Clock
-	log    logr.Logger
+	reader   ctrlclient.Reader
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	syncer   *status.Syncer
+	clients  *googlecloud.ClientPool
+	dryRun   bool
+	clock    clock.Clock
+	log      logr.Logger
 }
 
 func NewBigtableReconciler(
 	client ctrlclient.Client,
 	reader ctrlclient.Reader,
 	scheme *runtime.Scheme,
+	recorder record.EventRecorder,
+	dryRun bool,
 ) *BigtableAutoscalerReconciler {
 
 	log := ctrl.Log.WithName("controllers").WithName("BigtableAutoscaler")
-	syncer := status.NewSyncer(client.Status(), log)
+	syncer := status.NewSyncer(client.Status(), recorder, log)
 
 	r := &BigtableAutoscalerReconciler{
-		Client: client,
-		reader: reader,
-		scheme: scheme,
-		syncer: syncer,
-		log:    log,
+		Client:   client,
+		reader:   reader,
+		scheme:   scheme,
+		recorder: recorder,
+		syncer:   syncer,
+		clients:  googlecloud.NewClientPool(),
+		dryRun:   dryRun,
+		clock:    clock.RealClock{},
+		log:      log,
 	}
 
 	return r
 }
 
+// credentialsLabel marks the secrets holding service account keys. Only these secrets are watched,
+// so that the operator doesn't cache every secret of the cluster.
+const credentialsLabel = "bigtable.bigtable-autoscaler.com/credentials"
+
+// secretRefIndex indexes the autoscalers by the namespace/name of the secret they read credentials from.
+const secretRefIndex = ".spec.serviceAccountSecretRef"
+
+// autoscalerRefIndex indexes the capacity reservations by the name of the autoscaler they reference.
+const autoscalerRefIndex = ".spec.autoscalerRef.name"
+
 func (r *BigtableAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(&bigtablev2.BigtableAutoscaler{}, secretRefIndex, indexSecretRef)
+	if err != nil {
+		return fmt.Errorf("failed to index autoscalers by secret: %w", err)
+	}
+
+	err = mgr.GetFieldIndexer().IndexField(&bigtablev2.BigtableCapacityReservation{}, autoscalerRefIndex, func(obj runtime.Object) []string {
+		return []string{obj.(*bigtablev2.BigtableCapacityReservation).Spec.AutoscalerRef.Name}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index capacity reservations by autoscaler: %w", err)
+	}
+
+	secrets, err := newCredentialsInformer(mgr)
+	if err != nil {
+		return err
+	}
+
+	// Secrets are watched so that a rotated key rebuilds the clients and restarts the metrics sync.
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&bigtablev1.BigtableAutoscaler{}).
+		For(&bigtablev2.BigtableAutoscaler{}).
+		Watches(&source.Informer{Informer: secrets}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.autoscalersForSecret),
+		}).
+		Watches(&source.Kind{Type: &bigtablev2.BigtableCapacityReservation{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(autoscalerForReservation),
+		}).
 		Complete(r)
 }
 
+// indexSecretRef returns the namespace/name of the secret the autoscaler reads credentials from.
+func indexSecretRef(obj runtime.Object) []string {
+	key, ok := secretKey(obj.(*bigtablev2.BigtableAutoscaler))
+	if !ok {
+		return nil
+	}
+
+	return []string{key.String()}
+}
+
+// newCredentialsInformer returns an informer of the secrets labeled with credentialsLabel, run
+// by the manager. The manager's cache would list and watch every secret of the cluster instead.
+func newCredentialsInformer(mgr ctrl.Manager) (toolscache.SharedIndexInformer, error) {
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = credentialsLabel
+		}))
+	informer := factory.Core().V1().Secrets().Informer()
+
+	err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		factory.Start(stop)
+		<-stop
+
+		return nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add secrets informer: %w", err)
+	}
+
+	return informer, nil
+}
+
+// autoscalerForReservation returns the autoscaler whose floor the capacity reservation raises.
+func autoscalerForReservation(obj handler.MapObject) []reconcile.Request {
+	reservation, ok := obj.Object.(*bigtablev2.BigtableCapacityReservation)
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{{
+		NamespacedName: ctrlclient.ObjectKey{Namespace: reservation.Namespace, Name: reservation.Spec.AutoscalerRef.Name},
+	}}
+}
+
+// autoscalersForSecret returns the autoscalers reading credentials from the secret.
+func (r *BigtableAutoscalerReconciler) autoscalersForSecret(obj handler.MapObject) []reconcile.Request {
+	key := ctrlclient.ObjectKey{Namespace: obj.Meta.GetNamespace(), Name: obj.Meta.GetName()}
+
+	var autoscalers bigtablev2.BigtableAutoscalerList
+	if err := r.List(context.Background(), &autoscalers, ctrlclient.MatchingFields{secretRefIndex: key.String()}); err != nil {
+		r.log.Error(err, "failed to list autoscalers referencing secret", "secret", key)
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(autoscalers.Items))
+	for _, autoscaler := range autoscalers.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: ctrlclient.ObjectKey{Namespace: autoscaler.Namespace, Name: autoscaler.Name},
+		})
+	}
+
+	return requests
+}
+
 // +kubebuilder:rbac:groups=bigtable.bigtable-autoscaler.com,resources=bigtableautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=bigtable.bigtable-autoscaler.com,resources=bigtableautoscalers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=bigtable.bigtable-autoscaler.com,resources=bigtableautoscalers/finalizers,verbs=update
+// +kubebuilder:rbac:groups=bigtable.bigtable-autoscaler.com,resources=bigtablecapacityreservations,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 func (r *BigtableAutoscalerReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
-	r.clock = clock.RealClock{}
 
-	var autoscaler bigtablev1.BigtableAutoscaler
+	var autoscaler bigtablev2.BigtableAutoscaler
 	if err := r.Get(ctx, req.NamespacedName, &autoscaler); err != nil {
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
-			// For additional cleanup logic use finalizers.
+			// The finalizer already released its clients, unless it was removed by hand.
+			r.clients.Release(req.NamespacedName.String())
+
 			return ctrl.Result{}, nil
 		}
 
@@ -98,87 +239,629 @@ func (r *BigtableAutoscalerReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 
 	r.log.Info("Reconciling", "autoscaler", autoscaler.UID)
 
-	if autoscaler.Spec.MaxScaleDownNodes == nil || *autoscaler.Spec.MaxScaleDownNodes == 0 {
-		var defaultMaxScaleDownNodes int32 = 2
-		autoscaler.Spec.MaxScaleDownNodes = &defaultMaxScaleDownNodes
-	}
+	if !autoscaler.DeletionTimestamp.IsZero() {
+		if err := r.finalize(ctx, &autoscaler); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to finalize autoscaler: %w", err)
+		}
 
-	if autoscaler.Status.CurrentCPUUtilization == nil {
-		var cpuUsage int32 = 0
-		autoscaler.Status.CurrentCPUUtilization = &cpuUsage
+		return ctrl.Result{}, nil
 	}
 
-	if autoscaler.Status.CurrentNodes == nil {
-		var nodes int32 = 0
-		autoscaler.Status.CurrentNodes = &nodes
+	if !containsString(autoscaler.Finalizers, finalizerName) {
+		autoscaler.Finalizers = append(autoscaler.Finalizers, finalizerName)
+		if err := r.Update(ctx, &autoscaler); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer: %w", err)
+		}
 	}
 
-	credentialsJSON, err := r.getCredentialsJSON(ctx, autoscaler.Spec.ServiceAccountSecretRef, autoscaler.Namespace)
+	credentials, err := r.getCredentials(ctx, &autoscaler)
 	if err != nil {
+		var credentialsErr *googlecloud.CredentialsError
+		if goerrors.As(err, &credentialsErr) {
+			return r.credentialsInvalid(ctx, &autoscaler, credentialsErr)
+		}
+
 		return ctrl.Result{}, fmt.Errorf("failed to get credentials: %w", err)
 	}
 
 	clusterRef := autoscaler.Spec.BigtableClusterRef
-	googleCloudClient, err := googlecloud.NewClientFromCredentials(ctx, credentialsJSON, clusterRef.ProjectID, clusterRef.InstanceID)
+	autoscaler.Status.CredentialsSource = string(credentials.Source())
+	autoscaler.Status.ImpersonatedServiceAccount = credentials.ImpersonateServiceAccount
+
+	// The sync routine is stopped before getting the clients, which closes the previous ones when
+	// the credentials changed, so that it never reads metrics with a closed client.
+	r.syncer.Unregister(autoscaler.UID)
+	googleCloudClient, err := r.clients.Get(ctx, req.NamespacedName.String(), credentials,
+		clusterRef.ProjectID, clusterRef.InstanceID, clusterRef.ClusterID)
 	if err != nil {
+		r.setCondition(&autoscaler, bigtablev2.ConditionCredentialsValid, metav1.ConditionFalse, "ClientInitializationFailed", err.Error())
+		if statusErr := r.updateStatus(ctx, &autoscaler); statusErr != nil {
+			r.log.Error(statusErr, "failed to update autoscaler status")
+		}
+
 		return ctrl.Result{}, fmt.Errorf("failed to initialize googlecloud client: %w", err)
 	}
+	r.setCondition(&autoscaler, bigtablev2.ConditionCredentialsValid, metav1.ConditionTrue, "ClientInitialized",
+		fmt.Sprintf("google cloud clients were built from %s credentials", credentials.Source()))
 
-	r.syncer.Register(ctx, &autoscaler, googleCloudClient)
+	if !conditions.IsTrue(autoscaler.Status.Conditions, bigtablev2.ConditionMetricsAvailable) {
+		r.log.Info("Metrics are not available; skipping nodes calculation")
 
-	desiredNodes := nodes_calculator.CalcDesiredNodes(&autoscaler.Status, &autoscaler.Spec)
-	autoscaler.Status.DesiredNodes = &desiredNodes
+		if err = r.updateStatusAndSync(ctx, &autoscaler, googleCloudClient); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update autoscaler status: %w", err)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if metricsPredateScale(&autoscaler.Status) {
+		r.log.Info("Metrics were fetched before the last scale; waiting for the next fetch")
+
+		if err = r.updateStatusAndSync(ctx, &autoscaler, googleCloudClient); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update autoscaler status: %w", err)
+		}
+
+		return ctrl.Result{}, nil
+	}
 
 	now := r.clock.Now()
-	needUpdate := r.needUpdateNodes(&autoscaler.Status, now)
+
+	var reservations bigtablev2.BigtableCapacityReservationList
+	err = r.List(ctx, &reservations, ctrlclient.InNamespace(autoscaler.Namespace),
+		ctrlclient.MatchingFields{autoscalerRefIndex: autoscaler.Name})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list capacity reservations: %w", err)
+	}
+
+	// The nodes are computed with the limits and targets of the active schedule, if any, and the
+	// floor of the capacity reservations.
+	spec, schedule := nodes_calculator.ApplySchedules(&autoscaler.Spec, now)
+	r.setActiveSchedule(&autoscaler, schedule)
+
+	spec, reservedNodes := nodes_calculator.ApplyReservations(spec, reservations.Items, now)
+	autoscaler.Status.ReservedNodes = nil
+	if reservedNodes > 0 {
+		autoscaler.Status.ReservedNodes = &reservedNodes
+	}
+
+	nodes_calculator.AddSample(&autoscaler.Status)
+	nodes_calculator.AdvanceStrategy(&autoscaler.Status, spec)
+	recommendation, err := nodes_calculator.Recommend(&autoscaler.Status, spec)
+	if err != nil {
+		return r.strategyUnknown(ctx, &autoscaler, err)
+	}
+	requiredNodes := recommendation.Nodes
+	nodes_calculator.AddRecommendation(&autoscaler.Status, spec, requiredNodes, now)
+
+	scaleUpNodes, scaleDownNodes := nodes_calculator.CalcStabilizedRecommendations(&autoscaler.Status, spec, now)
+	autoscaler.Status.ScaleUpRecommendation = &scaleUpNodes
+	autoscaler.Status.ScaleDownRecommendation = &scaleDownNodes
+
+	effectiveMinNodes := nodes_calculator.CalcEffectiveMinNodes(&autoscaler.Status, spec)
+	autoscaler.Status.EffectiveMinNodes = &effectiveMinNodes
+
+	desiredNodes := nodes_calculator.CalcDesiredNodes(&autoscaler.Status, spec, now)
+	autoscaler.Status.DesiredNodes = &desiredNodes
+	blackout := nodes_calculator.ActiveScaleDownBlackout(&autoscaler.Spec, now)
+	r.setScalingLimitedCondition(&autoscaler, spec, blackout, requiredNodes, desiredNodes, effectiveMinNodes)
+	r.setCondition(&autoscaler, bigtablev2.ConditionScalingActive, metav1.ConditionTrue, "DesiredNodesComputed",
+		"the desired number of nodes was computed from the current metrics")
+
+	if autoscaler.Spec.Suspend {
+		r.setCondition(&autoscaler, bigtablev2.ConditionSuspended, metav1.ConditionTrue, "SuspendedBySpec",
+			"the autoscaler is suspended; the cluster is not resized")
+	} else {
+		r.setCondition(&autoscaler, bigtablev2.ConditionSuspended, metav1.ConditionFalse, "NotSuspended",
+			"the autoscaler is not suspended")
+	}
+
+	overridden := r.setManualOverrideCondition(&autoscaler, now)
+
+	recommend := r.recommendOnly(&autoscaler)
+	previousRecommendation := autoscaler.Status.RecommendedNodes
+	if recommend {
+		autoscaler.Status.RecommendedNodes = &desiredNodes
+		autoscaler.Status.RecommendationReason = recommendationReason(&autoscaler, recommendation, desiredNodes)
+	} else {
+		autoscaler.Status.RecommendedNodes = nil
+		autoscaler.Status.RecommendationReason = ""
+	}
+
+	needUpdate := r.needUpdateNodes(&autoscaler.Status, blackout)
+	if needUpdate && autoscaler.Spec.Suspend {
+		r.log.Info("The autoscaler is suspended; not scaling nodes", "desired", desiredNodes)
+		needUpdate = false
+	}
+
+	if needUpdate && overridden {
+		r.log.Info("The nodes were changed manually; not scaling nodes", "desired", desiredNodes)
+		needUpdate = false
+	}
+
+	if needUpdate && recommend {
+		r.log.Info("The autoscaler only recommends; not scaling nodes", "desired", desiredNodes)
+
+		// The event is only emitted when the recommendation changes, not on every reconcile.
+		if previousRecommendation == nil || *previousRecommendation != desiredNodes {
+			r.recorder.Eventf(&autoscaler, corev1.EventTypeNormal, "ScaleRecommended", "Would scale from %d to %d nodes: %s",
+				*autoscaler.Status.CurrentNodes, desiredNodes, autoscaler.Status.RecommendationReason)
+		}
+		needUpdate = false
+	}
+
 	if needUpdate {
-		r.log.Info("Updating last scale time")
-		autoscaler.Status.LastScaleTime = &metav1.Time{Time: now}
+		currentNodes := *autoscaler.Status.CurrentNodes
 
 		r.log.Info("Metric read", "Increasing node count to", desiredNodes)
-		err := scaleNodes(ctx, credentialsJSON, &clusterRef, desiredNodes)
+		err := googleCloudClient.UpdateNodeCount(clusterRef.ClusterID, desiredNodes)
 		if err != nil {
 			r.log.Error(err, "failed to update nodes")
+			r.recorder.Eventf(&autoscaler, corev1.EventTypeWarning, "ScaleFailed",
+				"Failed to scale from %d to %d nodes: %v", currentNodes, desiredNodes, err)
+			r.setCondition(&autoscaler, bigtablev2.ConditionScalingActive, metav1.ConditionFalse, "FailedUpdateCluster", err.Error())
+		} else {
+			reason := "ScaledUp"
+			if desiredNodes < currentNodes {
+				reason = "ScaledDown"
+			}
+			r.recorder.Eventf(&autoscaler, corev1.EventTypeNormal, reason, "Scaled from %d to %d nodes", currentNodes, desiredNodes)
+
+			change := desiredNodes - currentNodes
+			nodes_calculator.AddScaleEvent(&autoscaler.Status, spec, change, now)
+			nodes_calculator.AdoptNodes(&autoscaler.Status, desiredNodes)
+			autoscaler.Status.LastScaleTime = &metav1.Time{Time: now}
 		}
 	}
 
-	if err = r.Status().Update(ctx, &autoscaler); err != nil {
+	if err = r.updateStatusAndSync(ctx, &autoscaler, googleCloudClient); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to update autoscaler status: %w", err)
 	}
 
 	return ctrl.Result{}, nil
 }
 
-func (r *BigtableAutoscalerReconciler) getCredentialsJSON(ctx context.Context, secretRef bigtablev1.ServiceAccountSecretRef, autoscalerNamespace string) ([]byte, error) {
-	var namespace string
+// updateStatusAndSync writes the status, then restarts the sync routine from the written
+// autoscaler, so that the routine starts from its latest version. The routine is restarted
+// even when the write fails, to keep reading metrics until the next reconcile.
+func (r *BigtableAutoscalerReconciler) updateStatusAndSync(
+	ctx context.Context,
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	googleCloudClient googlecloud.GoogleCloudClient,
+) error {
+	err := r.updateStatus(ctx, autoscaler)
+	r.syncer.Register(ctx, autoscaler, googleCloudClient)
+
+	return err
+}
 
-	if secretRef.Namespace == nil || *secretRef.Namespace == "" {
-		namespace = autoscalerNamespace
-	} else {
-		namespace = *secretRef.Namespace
+// finalize stops the sync routine of a deleted autoscaler and runs its onDelete policy before
+// removing the finalizer, so that the autoscaler is only gone once the cluster was left as asked.
+// As when reconciling, a suspended autoscaler leaves the cluster as it is, and one that only
+// recommends reports what it would do. When the policy can't be run, e.g. because the secret was
+// deleted along with the namespace, it is retried for onDeleteTimeout, then given up with a
+// warning event.
+func (r *BigtableAutoscalerReconciler) finalize(ctx context.Context, autoscaler *bigtablev2.BigtableAutoscaler) error {
+	if !containsString(autoscaler.Finalizers, finalizerName) {
+		return nil
 	}
 
-	var secret corev1.Secret
-	key := ctrlclient.ObjectKey{
-		Name:      *secretRef.Name,
-		Namespace: namespace,
+	r.syncer.Unregister(autoscaler.UID)
+
+	owner := ctrlclient.ObjectKey{Namespace: autoscaler.Namespace, Name: autoscaler.Name}.String()
+
+	nodes, ok := onDeleteNodes(autoscaler)
+	switch {
+	case !ok:
+		// The cluster is left as it is.
+	case autoscaler.Spec.Suspend:
+		r.recorder.Eventf(autoscaler, corev1.EventTypeNormal, "OnDeleteSkipped",
+			"The autoscaler is suspended; leaving the cluster at its size instead of scaling to %d nodes", nodes)
+	case r.recommendOnly(autoscaler):
+		r.recorder.Eventf(autoscaler, corev1.EventTypeNormal, "ScaleRecommended",
+			"Would scale to %d nodes as the autoscaler is deleted", nodes)
+	default:
+		if err := r.runOnDelete(ctx, autoscaler, owner, nodes); err != nil {
+			if !r.giveUpOnDelete(autoscaler, err) {
+				return err
+			}
+
+			r.log.Error(err, "giving up the onDelete policy", "autoscaler", autoscaler.UID)
+			r.recorder.Eventf(autoscaler, corev1.EventTypeWarning, "OnDeleteFailed",
+				"Removing the finalizer without scaling to %d nodes: %v", nodes, err)
+		}
+	}
+
+	r.clients.Release(owner)
+
+	autoscaler.Finalizers = removeString(autoscaler.Finalizers, finalizerName)
+	if err := r.Update(ctx, autoscaler); err != nil {
+		return fmt.Errorf("failed to remove finalizer: %w", err)
+	}
+
+	return nil
+}
+
+// recommendOnly tells whether the decisions of the autoscaler are only recorded, in Recommend mode
+// or when the operator runs with --dry-run, rather than applied to the cluster.
+func (r *BigtableAutoscalerReconciler) recommendOnly(autoscaler *bigtablev2.BigtableAutoscaler) bool {
+	return r.dryRun || autoscaler.Spec.Mode == bigtablev2.RecommendAutoscalerMode
+}
+
+// runOnDelete scales the cluster of a deleted autoscaler to the nodes of its onDelete policy.
+func (r *BigtableAutoscalerReconciler) runOnDelete(
+	ctx context.Context,
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	owner string,
+	nodes int32,
+) error {
+	r.log.Info("Running onDelete policy", "action", autoscaler.Spec.OnDelete.Action, "nodes", nodes)
+
+	credentials, err := r.getCredentials(ctx, autoscaler)
+	if err != nil {
+		return fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	clusterRef := autoscaler.Spec.BigtableClusterRef
+	googleCloudClient, err := r.clients.Get(ctx, owner, credentials, clusterRef.ProjectID, clusterRef.InstanceID, clusterRef.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to initialize googlecloud client: %w", err)
+	}
+
+	if err := googleCloudClient.UpdateNodeCount(clusterRef.ClusterID, nodes); err != nil {
+		return fmt.Errorf("failed to run onDelete policy: %w", err)
+	}
+
+	return nil
+}
+
+// giveUpOnDelete tells whether the onDelete policy of a deleted autoscaler failing with err is
+// given up rather than retried: invalid credentials won't get fixed once the autoscaler is deleted,
+// and other errors are only retried for onDeleteTimeout.
+func (r *BigtableAutoscalerReconciler) giveUpOnDelete(autoscaler *bigtablev2.BigtableAutoscaler, err error) bool {
+	var credentialsErr *googlecloud.CredentialsError
+	if goerrors.As(err, &credentialsErr) {
+		return true
 	}
 
+	return !r.clock.Now().Before(autoscaler.DeletionTimestamp.Add(onDeleteTimeout))
+}
+
+// onDeleteNodes returns the number of nodes the cluster is scaled to when the autoscaler is
+// deleted, or false when it is left as it is.
+func onDeleteNodes(autoscaler *bigtablev2.BigtableAutoscaler) (int32, bool) {
+	onDelete := autoscaler.Spec.OnDelete
+	if onDelete == nil {
+		return 0, false
+	}
+
+	switch onDelete.Action {
+	case bigtablev2.ScaleToMinOnDeleteAction:
+		nodes := *autoscaler.Spec.MinNodes
+
+		// Bigtable refuses to go below the nodes needed to hold the stored data.
+		if effectiveMinNodes := autoscaler.Status.EffectiveMinNodes; effectiveMinNodes != nil && *effectiveMinNodes > nodes {
+			nodes = *effectiveMinNodes
+		}
+
+		return nodes, true
+	case bigtablev2.ScaleToOnDeleteAction:
+		if onDelete.Nodes == nil {
+			return 0, false
+		}
+
+		return *onDelete.Nodes, true
+	default:
+		return 0, false
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removeString(values []string, value string) []string {
+	var result []string
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+func (r *BigtableAutoscalerReconciler) updateStatus(ctx context.Context, autoscaler *bigtablev2.BigtableAutoscaler) error {
+	conditions.SetReady(&autoscaler.Status.Conditions, autoscaler.Generation)
+
+	return r.Status().Update(ctx, autoscaler)
+}
+
+func (r *BigtableAutoscalerReconciler) setCondition(
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	conditionType string,
+	status metav1.ConditionStatus,
+	reason, message string,
+) {
+	conditions.Set(&autoscaler.Status.Conditions, bigtablev2.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: autoscaler.Generation,
+		LastTransitionTime: metav1.NewTime(r.clock.Now()),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setActiveSchedule records the active schedule, emitting an event when it changes.
+func (r *BigtableAutoscalerReconciler) setActiveSchedule(autoscaler *bigtablev2.BigtableAutoscaler, schedule *bigtablev2.Schedule) {
+	name := ""
+	if schedule != nil {
+		name = schedule.Name
+	}
+
+	previous := autoscaler.Status.ActiveSchedule
+	if name == previous {
+		return
+	}
+
+	if previous != "" {
+		r.recorder.Eventf(autoscaler, corev1.EventTypeNormal, "ScheduleEnded", "Schedule %s ended", previous)
+	}
+
+	if name != "" {
+		r.recorder.Eventf(autoscaler, corev1.EventTypeNormal, "ScheduleStarted", "Schedule %s started", name)
+	}
+
+	autoscaler.Status.ActiveSchedule = name
+}
+
+// setManualOverrideCondition tells whether scaling is held off because the nodes were changed
+// outside of the autoscaler, and takes the cluster over again once the grace period is over.
+func (r *BigtableAutoscalerReconciler) setManualOverrideCondition(autoscaler *bigtablev2.BigtableAutoscaler, now time.Time) bool {
+	until, ok := nodes_calculator.ManualOverrideUntil(&autoscaler.Status, &autoscaler.Spec)
+	if ok && now.Before(until) {
+		r.setCondition(autoscaler, bigtablev2.ConditionManualOverride, metav1.ConditionTrue, "ManualOverrideDetected",
+			fmt.Sprintf("the number of nodes was changed to %d outside of the autoscaler; scaling resumes at %s",
+				autoscaler.Status.ManualOverride.Nodes, until.UTC().Format(time.RFC3339)))
+
+		return true
+	}
+
+	if ok {
+		nodes := autoscaler.Status.ManualOverride.Nodes
+		r.recorder.Eventf(autoscaler, corev1.EventTypeNormal, "ManualOverrideExpired",
+			"The grace period of the manual change to %d nodes is over; scaling resumes", nodes)
+		nodes_calculator.AdoptNodes(&autoscaler.Status, nodes)
+	}
+
+	r.setCondition(autoscaler, bigtablev2.ConditionManualOverride, metav1.ConditionFalse, "NoManualOverride",
+		"the number of nodes was not changed outside of the autoscaler")
+
+	return false
+}
+
+func (r *BigtableAutoscalerReconciler) setScalingLimitedCondition(
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	spec *bigtablev2.BigtableAutoscalerSpec,
+	blackout *bigtablev2.ScaleDownBlackout,
+	requiredNodes, desiredNodes, effectiveMinNodes int32,
+) {
+	currentNodes := autoscaler.Status.CurrentNodes
+
+	// boundedNodes is what the cluster would be scaled to without the stabilization windows and
+	// the scaling policies.
+	boundedNodes := requiredNodes
+	if boundedNodes > *spec.MaxNodes {
+		boundedNodes = *spec.MaxNodes
+	}
+	if boundedNodes < effectiveMinNodes {
+		boundedNodes = effectiveMinNodes
+	}
+
+	switch {
+	case blackout != nil && currentNodes != nil && desiredNodes < *currentNodes:
+		message := fmt.Sprintf("scaling down from %d to %d nodes is blocked by the blackout %s",
+			*currentNodes, desiredNodes, blackout.Name)
+
+		// The event is only emitted when the blackout starts blocking, not on every reconcile.
+		previous := conditions.Find(autoscaler.Status.Conditions, bigtablev2.ConditionScalingLimited)
+		if previous == nil || previous.Status != metav1.ConditionTrue || previous.Reason != "ScaleDownBlocked" {
+			r.recorder.Event(autoscaler, corev1.EventTypeNormal, "ScaleDownBlocked", message)
+		}
+
+		r.setCondition(autoscaler, bigtablev2.ConditionScalingLimited, metav1.ConditionTrue, "ScaleDownBlocked", message)
+	case effectiveMinNodes > *spec.MinNodes && requiredNodes < effectiveMinNodes && desiredNodes == effectiveMinNodes:
+		r.setCondition(autoscaler, bigtablev2.ConditionScalingLimited, metav1.ConditionTrue, "StorageFloor",
+			fmt.Sprintf("the required number of nodes (%d) is below the %d nodes needed to hold the stored data", requiredNodes, effectiveMinNodes))
+	case requiredNodes > *spec.MaxNodes && desiredNodes == *spec.MaxNodes:
+		r.setCondition(autoscaler, bigtablev2.ConditionScalingLimited, metav1.ConditionTrue, "TooManyNodes",
+			fmt.Sprintf("the required number of nodes (%d) is above MaxNodes (%d)", requiredNodes, *spec.MaxNodes))
+	case requiredNodes < *spec.MinNodes && desiredNodes == *spec.MinNodes:
+		r.setCondition(autoscaler, bigtablev2.ConditionScalingLimited, metav1.ConditionTrue, "TooFewNodes",
+			fmt.Sprintf("the required number of nodes (%d) is below MinNodes (%d)", requiredNodes, *spec.MinNodes))
+	case currentNodes != nil && desiredNodes == *currentNodes && desiredNodes != boundedNodes:
+		message := fmt.Sprintf("scaling from %d to %d nodes is held back by the stabilization windows or the scaling policies",
+			desiredNodes, boundedNodes)
+
+		// As for blackouts, the event is only emitted when the cluster starts being held back.
+		previous := conditions.Find(autoscaler.Status.Conditions, bigtablev2.ConditionScalingLimited)
+		if previous == nil || previous.Status != metav1.ConditionTrue || previous.Reason != "ScaleSkippedCooldown" {
+			r.recorder.Event(autoscaler, corev1.EventTypeNormal, "ScaleSkippedCooldown", message)
+		}
+
+		r.setCondition(autoscaler, bigtablev2.ConditionScalingLimited, metav1.ConditionTrue, "ScaleSkippedCooldown", message)
+	default:
+		r.setCondition(autoscaler, bigtablev2.ConditionScalingLimited, metav1.ConditionFalse, "DesiredWithinRange",
+			"the desired number of nodes is within MinNodes and MaxNodes")
+	}
+}
+
+// recommendationReason tells what the desired number of nodes follows: the limit clamping it, or
+// the explanation of the scaling strategy.
+func recommendationReason(
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	recommendation nodes_calculator.Recommendation,
+	desiredNodes int32,
+) string {
+	if limited := conditions.Find(autoscaler.Status.Conditions, bigtablev2.ConditionScalingLimited); limited != nil &&
+		limited.Status == metav1.ConditionTrue {
+		return limited.Message
+	}
+
+	if desiredNodes != recommendation.Nodes {
+		return recommendation.Explanation + ", held back by the stabilization windows or the scaling policies"
+	}
+
+	return recommendation.Explanation
+}
+
+// getCredentials returns the credentials of the autoscaler: the service account key of the referenced
+// secret, or Application Default Credentials when no secret is referenced, impersonating
+// spec.impersonateServiceAccount when it is set.
+func (r *BigtableAutoscalerReconciler) getCredentials(ctx context.Context, autoscaler *bigtablev2.BigtableAutoscaler) (googlecloud.Credentials, error) {
+	credentials := googlecloud.Credentials{
+		ImpersonateServiceAccount: autoscaler.Spec.ImpersonateServiceAccount,
+		Delegates:                 autoscaler.Spec.ImpersonationDelegates,
+	}
+
+	key, ok := secretKey(autoscaler)
+	if !ok {
+		return credentials, nil
+	}
+
+	var secret corev1.Secret
 	if err := r.reader.Get(ctx, key, &secret); err != nil {
 		if errors.IsNotFound(err) {
-			r.log.Info(err.Error())
+			return googlecloud.Credentials{}, &googlecloud.CredentialsError{
+				Reason:  googlecloud.SecretNotFoundReason,
+				Message: fmt.Sprintf("secret %s not found", key),
+			}
 		}
-		r.log.Info(err.Error())
+
+		return googlecloud.Credentials{}, fmt.Errorf("failed to get secret %s: %w", key, err)
 	}
 
-	credentialsJSON := secret.Data[*secretRef.Key]
+	secretRef := autoscaler.Spec.ServiceAccountSecretRef
+	credentialsJSON, ok := secret.Data[*secretRef.Key]
+	if !ok || len(credentialsJSON) == 0 {
+		return googlecloud.Credentials{}, &googlecloud.CredentialsError{
+			Reason:  googlecloud.KeyMissingReason,
+			Message: fmt.Sprintf("secret %s has no key %q", key, *secretRef.Key),
+		}
+	}
+
+	credentials.JSON = credentialsJSON
+	if err := credentials.Validate(); err != nil {
+		return googlecloud.Credentials{}, err
+	}
 
-	return credentialsJSON, nil
+	return credentials, nil
 }
 
-func (r *BigtableAutoscalerReconciler) needUpdateNodes(status *bigtablev1.BigtableAutoscalerStatus, now time.Time) bool {
-	scaleDownInterval := 1 * time.Minute
+// credentialsInvalid reports credentials that can't be used until the secret or the autoscaler
+// changes, and retries later rather than in a tight loop. The sync routine is stopped and the
+// clients built from the previous credentials are released, so that they aren't used meanwhile.
+func (r *BigtableAutoscalerReconciler) credentialsInvalid(
+	ctx context.Context,
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	credentialsErr *googlecloud.CredentialsError,
+) (ctrl.Result, error) {
+	reason := string(credentialsErr.Reason)
+
+	r.syncer.Unregister(autoscaler.UID)
+	r.clients.Release(ctrlclient.ObjectKey{Namespace: autoscaler.Namespace, Name: autoscaler.Name}.String())
+
+	// The event is only emitted when the failure starts or changes, not on every retry.
+	condition := conditions.Find(autoscaler.Status.Conditions, bigtablev2.ConditionCredentialsValid)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != reason {
+		r.recorder.Eventf(autoscaler, corev1.EventTypeWarning, "CredentialsMissing", "%s: %s", reason, credentialsErr.Message)
+	}
+
+	r.setCondition(autoscaler, bigtablev2.ConditionCredentialsValid, metav1.ConditionFalse, reason, credentialsErr.Message)
+	if err := r.updateStatus(ctx, autoscaler); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update autoscaler status: %w", err)
+	}
+
+	condition = conditions.Find(autoscaler.Status.Conditions, bigtablev2.ConditionCredentialsValid)
+	backoff := credentialsBackoff(condition.LastTransitionTime.Time, r.clock.Now())
+	r.log.Info("Invalid credentials; retrying later", "reason", reason, "backoff", backoff)
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
 
+// strategyUnknown reports a scaling strategy that isn't registered, which the webhook only lets
+// through when it is bypassed or when the operator was downgraded. The cluster is left as it is
+// until the spec changes.
+func (r *BigtableAutoscalerReconciler) strategyUnknown(
+	ctx context.Context,
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	strategyErr error,
+) (ctrl.Result, error) {
+	// The event is only emitted when the failure starts, not on every reconcile.
+	condition := conditions.Find(autoscaler.Status.Conditions, bigtablev2.ConditionScalingActive)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "UnknownStrategy" {
+		r.recorder.Event(autoscaler, corev1.EventTypeWarning, "UnknownStrategy", strategyErr.Error())
+	}
+
+	r.setCondition(autoscaler, bigtablev2.ConditionScalingActive, metav1.ConditionFalse, "UnknownStrategy", strategyErr.Error())
+	if err := r.updateStatus(ctx, autoscaler); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update autoscaler status: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// credentialsBackoff returns how long to wait before retrying credentials that are invalid since
+// failingSince. Waiting as long as they have been failing doubles the delay on each retry.
+func credentialsBackoff(failingSince, now time.Time) time.Duration {
+	backoff := now.Sub(failingSince)
+	if backoff < minCredentialsBackoff {
+		return minCredentialsBackoff
+	}
+
+	if backoff > maxCredentialsBackoff {
+		return maxCredentialsBackoff
+	}
+
+	return backoff
+}
+
+// secretKey returns the namespace/name of the secret the autoscaler reads credentials from, which
+// defaults to the autoscaler's namespace, or false when it uses Application Default Credentials.
+func secretKey(autoscaler *bigtablev2.BigtableAutoscaler) (ctrlclient.ObjectKey, bool) {
+	secretRef := autoscaler.Spec.ServiceAccountSecretRef
+	if secretRef == nil || secretRef.Name == nil {
+		return ctrlclient.ObjectKey{}, false
+	}
+
+	namespace := autoscaler.Namespace
+	if secretRef.Namespace != nil && *secretRef.Namespace != "" {
+		namespace = *secretRef.Namespace
+	}
+
+	return ctrlclient.ObjectKey{Namespace: namespace, Name: *secretRef.Name}, true
+}
+
+// metricsPredateScale tells whether the metrics were fetched before the last scale. They were
+// read with the previous number of nodes, so that scaling again from them would count the same
+// load twice.
+func metricsPredateScale(status *bigtablev2.BigtableAutoscalerStatus) bool {
+	if status.LastScaleTime == nil {
+		return false
+	}
+
+	return status.LastFetchTime == nil || !status.LastFetchTime.After(status.LastScaleTime.Time)
+}
+
+// needUpdateNodes tells whether the cluster must be resized to the desired number of nodes. During
+// a scale down blackout, only scaling up is allowed.
+func (r *BigtableAutoscalerReconciler) needUpdateNodes(status *bigtablev2.BigtableAutoscalerStatus, blackout *bigtablev2.ScaleDownBlackout) bool {
 	if status.CurrentNodes == nil || status.DesiredNodes == nil {
 		return false
 	}
@@ -191,30 +874,12 @@ func (r *BigtableAutoscalerReconciler) needUpdateNodes(status *bigtablev1.Bigtab
 		return false
 	}
 
-	if status.LastScaleTime != nil && now.Before(status.LastScaleTime.Time.Add(scaleDownInterval)) {
-		r.log.Info(
-			"Too soon to scale",
-			"time to wait", scaleDownInterval,
-			"now", now.String(),
-			"last scale time", status.LastScaleTime,
-			"desired", desiredNodes,
-			"current", currentNodes,
-		)
-
+	if desiredNodes < currentNodes && blackout != nil {
+		r.log.Info("Scale down blackout in effect; not scaling down nodes", "blackout", blackout.Name,
+			"desired", desiredNodes, "current", currentNodes)
 		return false
-
 	}
 
 	r.log.Info("The desired number of nodes is different than current: scaling", "desired", desiredNodes, "current", currentNodes)
 	return true
 }
-
-func scaleNodes(ctx context.Context, credentialsJSON []byte, clusterRef *bigtablev1.BigtableClusterRef, desiredNodes int32) error {
-	client, err := bigtable.NewInstanceAdminClient(ctx, clusterRef.ProjectID, option.WithCredentialsJSON(credentialsJSON))
-
-	if err != nil {
-		return err
-	}
-
-	return client.UpdateCluster(ctx, clusterRef.InstanceID, clusterRef.ClusterID, desiredNodes)
-}
@@ -0,0 +1,507 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/mocks"
+	"bigtable-autoscaler.com/m/v2/pkg/conditions"
+	"bigtable-autoscaler.com/m/v2/pkg/googlecloud"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+	"bigtable-autoscaler.com/m/v2/pkg/status"
+)
+
+var testNow = time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+var autoscalerKey = ctrlclient.ObjectKey{Namespace: "default", Name: "autoscaler"}
+
+func testAutoscaler() *bigtablev2.BigtableAutoscaler {
+	return &bigtablev2.BigtableAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       autoscalerKey.Name,
+			Namespace:  autoscalerKey.Namespace,
+			UID:        types.UID("autoscaler-uid"),
+			Finalizers: []string{finalizerName},
+		},
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			MinNodes:          pointer.Int32(1),
+			MaxNodes:          pointer.Int32(10),
+			MaxScaleDownNodes: pointer.Int32(2),
+			Metrics: []bigtablev2.MetricSpec{{
+				Type: bigtablev2.CPUMetricSourceType,
+				Target: bigtablev2.MetricTarget{
+					Type:               bigtablev2.UtilizationMetricType,
+					AverageUtilization: pointer.Int32(50),
+				},
+			}},
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
+				ProjectID:  "cool-project",
+				InstanceID: "my-instance-id",
+				ClusterID:  "my-cluster-id",
+			},
+		},
+	}
+}
+
+// syncedAutoscaler returns an autoscaler whose sync routine read the CPU utilization of its 4 nodes.
+func syncedAutoscaler(cpu int32) *bigtablev2.BigtableAutoscaler {
+	autoscaler := testAutoscaler()
+	autoscaler.Status = bigtablev2.BigtableAutoscalerStatus{
+		CurrentNodes: pointer.Int32(4),
+		CurrentMetrics: []bigtablev2.MetricStatus{{
+			Type:    bigtablev2.CPUMetricSourceType,
+			Current: bigtablev2.MetricValueStatus{AverageUtilization: pointer.Int32(cpu)},
+		}},
+		CurrentStorageUtilization: pointer.Int32(10),
+		Conditions: []bigtablev2.Condition{{
+			Type:   bigtablev2.ConditionMetricsAvailable,
+			Status: metav1.ConditionTrue,
+			Reason: "MetricsFetched",
+		}},
+	}
+
+	return autoscaler
+}
+
+// deletedAutoscaler returns an autoscaler deleted deletedFor ago, scaling its cluster to its
+// minimum number of nodes on deletion.
+func deletedAutoscaler(deletedFor time.Duration) *bigtablev2.BigtableAutoscaler {
+	autoscaler := testAutoscaler()
+	autoscaler.DeletionTimestamp = &metav1.Time{Time: testNow.Add(-deletedFor)}
+	autoscaler.Spec.OnDelete = &bigtablev2.OnDeletePolicy{Action: bigtablev2.ScaleToMinOnDeleteAction}
+
+	return autoscaler
+}
+
+// newTestReconciler returns a reconciler reading the objects from a fake client, whose Google
+// Cloud clients are dialed by dial.
+func newTestReconciler(t *testing.T, dial googlecloud.DialFunc, objects ...runtime.Object) (*BigtableAutoscalerReconciler, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, bigtablev2.AddToScheme(scheme))
+
+	client := fake.NewFakeClientWithScheme(scheme, objects...)
+	recorder := record.NewFakeRecorder(100)
+	log := ctrl.Log.WithName("test")
+
+	r := &BigtableAutoscalerReconciler{
+		Client:   client,
+		reader:   client,
+		scheme:   scheme,
+		recorder: recorder,
+		syncer:   status.NewSyncer(client.Status(), recorder, log),
+		clients:  googlecloud.NewClientPoolWithDialer(dial),
+		clock:    clocktesting.NewFakeClock(testNow),
+		log:      log,
+	}
+
+	return r, recorder
+}
+
+// dialBigtable returns a dialer handing out the Bigtable client.
+func dialBigtable(bigtableClient *mocks.BigtableClient) googlecloud.DialFunc {
+	bigtableClient.On("Close").Return(nil)
+
+	return func(ctx context.Context, credentials googlecloud.Credentials, projectID string) (googlecloud.MetricClient, googlecloud.BigtableClient, error) {
+		metricClient := &mocks.MetricClient{}
+		metricClient.On("Close").Return(nil)
+
+		return metricClient, bigtableClient, nil
+	}
+}
+
+// reconcileAutoscaler reconciles the test autoscaler, stopping the sync routine it starts before
+// its first tick.
+func reconcileAutoscaler(t *testing.T, r *BigtableAutoscalerReconciler) *bigtablev2.BigtableAutoscaler {
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: autoscalerKey})
+	assert.NoError(t, err)
+
+	var autoscaler bigtablev2.BigtableAutoscaler
+	assert.NoError(t, r.Get(context.Background(), autoscalerKey, &autoscaler))
+	r.syncer.Unregister(autoscaler.UID)
+
+	return &autoscaler
+}
+
+// eventReasons returns the type and reason of the events recorded so far.
+func eventReasons(recorder *record.FakeRecorder) []string {
+	var reasons []string
+
+	for {
+		select {
+		case event := <-recorder.Events:
+			fields := strings.SplitN(event, " ", 3)
+			reasons = append(reasons, fields[0]+" "+fields[1])
+		default:
+			return reasons
+		}
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	dialFailed := func(ctx context.Context, credentials googlecloud.Credentials, projectID string) (googlecloud.MetricClient, googlecloud.BigtableClient, error) {
+		return nil, nil, errors.New("failed to dial")
+	}
+
+	tests := map[string]struct {
+		autoscaler        *bigtablev2.BigtableAutoscaler
+		dryRun            bool
+		dialFails         bool
+		expectedErr       bool
+		expectedNodes     *int32
+		expectedFinalizer bool
+		expectedEvents    []string
+	}{
+		"runs the onDelete policy": {
+			autoscaler:    deletedAutoscaler(time.Minute),
+			expectedNodes: pointer.Int32(1),
+		},
+		"gives up on a deleted secret": {
+			autoscaler: func() *bigtablev2.BigtableAutoscaler {
+				autoscaler := deletedAutoscaler(time.Minute)
+				autoscaler.Spec.ServiceAccountSecretRef = &bigtablev2.ServiceAccountSecretRef{
+					Name: pointer.String("example-service-account"),
+					Key:  pointer.String("service-account"),
+				}
+
+				return autoscaler
+			}(),
+			expectedEvents: []string{"Warning OnDeleteFailed"},
+		},
+		"only recommends in Recommend mode": {
+			autoscaler: func() *bigtablev2.BigtableAutoscaler {
+				autoscaler := deletedAutoscaler(time.Minute)
+				autoscaler.Spec.Mode = bigtablev2.RecommendAutoscalerMode
+
+				return autoscaler
+			}(),
+			expectedEvents: []string{"Normal ScaleRecommended"},
+		},
+		"only recommends with dry run": {
+			autoscaler:     deletedAutoscaler(time.Minute),
+			dryRun:         true,
+			expectedEvents: []string{"Normal ScaleRecommended"},
+		},
+		"leaves a suspended cluster": {
+			autoscaler: func() *bigtablev2.BigtableAutoscaler {
+				autoscaler := deletedAutoscaler(time.Minute)
+				autoscaler.Spec.Suspend = true
+
+				return autoscaler
+			}(),
+			expectedEvents: []string{"Normal OnDeleteSkipped"},
+		},
+		"retries failing clients": {
+			autoscaler:        deletedAutoscaler(time.Minute),
+			dialFails:         true,
+			expectedErr:       true,
+			expectedFinalizer: true,
+		},
+		"gives up failing clients after the timeout": {
+			autoscaler:     deletedAutoscaler(onDeleteTimeout),
+			dialFails:      true,
+			expectedEvents: []string{"Warning OnDeleteFailed"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bigtableClient := &mocks.BigtableClient{}
+			bigtableClient.On("UpdateCluster", mock.Anything, "my-instance-id", "my-cluster-id", mock.Anything).Return(nil)
+
+			dial := dialBigtable(bigtableClient)
+			if test.dialFails {
+				dial = dialFailed
+			}
+
+			r, recorder := newTestReconciler(t, dial, test.autoscaler)
+			r.dryRun = test.dryRun
+
+			_, err := r.Reconcile(ctrl.Request{NamespacedName: autoscalerKey})
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if test.expectedNodes != nil {
+				bigtableClient.AssertCalled(t, "UpdateCluster", mock.Anything, "my-instance-id", "my-cluster-id", *test.expectedNodes)
+			} else {
+				bigtableClient.AssertNotCalled(t, "UpdateCluster", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			var autoscaler bigtablev2.BigtableAutoscaler
+			assert.NoError(t, r.Get(context.Background(), autoscalerKey, &autoscaler))
+			assert.Equal(t, test.expectedFinalizer, containsString(autoscaler.Finalizers, finalizerName))
+			assert.Equal(t, test.expectedEvents, eventReasons(recorder))
+			assert.Zero(t, r.clients.Size())
+		})
+	}
+}
+
+func TestReconcileScaleSkippedCooldown(t *testing.T) {
+	autoscaler := syncedAutoscaler(100)
+	autoscaler.Spec.Behavior = &bigtablev2.BigtableAutoscalerBehavior{
+		ScaleUp: &bigtablev2.ScalingRules{StabilizationWindowSeconds: pointer.Int32(300)},
+	}
+	autoscaler.Status.Recommendations = []bigtablev2.Recommendation{
+		{Time: metav1.NewTime(testNow.Add(-time.Minute)), Nodes: 4},
+	}
+
+	bigtableClient := &mocks.BigtableClient{}
+	r, recorder := newTestReconciler(t, dialBigtable(bigtableClient), autoscaler)
+
+	reconcileAutoscaler(t, r)
+	reconciled := reconcileAutoscaler(t, r)
+
+	bigtableClient.AssertNotCalled(t, "UpdateCluster", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, []string{"Normal ScaleSkippedCooldown"}, eventReasons(recorder))
+
+	limited := conditions.Find(reconciled.Status.Conditions, bigtablev2.ConditionScalingLimited)
+	if assert.NotNil(t, limited) {
+		assert.Equal(t, metav1.ConditionTrue, limited.Status)
+		assert.Equal(t, "ScaleSkippedCooldown", limited.Reason)
+	}
+}
+
+func TestReconcileScalesOncePerFetch(t *testing.T) {
+	autoscaler := syncedAutoscaler(100)
+
+	bigtableClient := &mocks.BigtableClient{}
+	bigtableClient.On("UpdateCluster", mock.Anything, "my-instance-id", "my-cluster-id", int32(8)).Return(nil)
+	r, recorder := newTestReconciler(t, dialBigtable(bigtableClient), autoscaler)
+
+	reconcileAutoscaler(t, r)
+	reconciled := reconcileAutoscaler(t, r)
+
+	bigtableClient.AssertNumberOfCalls(t, "UpdateCluster", 1)
+	assert.Equal(t, []string{"Normal ScaledUp"}, eventReasons(recorder))
+	assert.Equal(t, int32(4), *reconciled.Status.CurrentNodes)
+	assert.Equal(t, int32(8), *reconciled.Status.LastAppliedNodes)
+	assert.True(t, testNow.Equal(reconciled.Status.LastScaleTime.Time))
+}
+
+func TestReconcileSuspended(t *testing.T) {
+	autoscaler := syncedAutoscaler(100)
+	autoscaler.Spec.Suspend = true
+
+	bigtableClient := &mocks.BigtableClient{}
+	r, _ := newTestReconciler(t, dialBigtable(bigtableClient), autoscaler)
+
+	reconciled := reconcileAutoscaler(t, r)
+
+	bigtableClient.AssertNotCalled(t, "UpdateCluster", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, int32(8), *reconciled.Status.DesiredNodes)
+	assert.Equal(t, int32(4), *reconciled.Status.CurrentNodes)
+	assert.True(t, conditions.IsTrue(reconciled.Status.Conditions, bigtablev2.ConditionSuspended))
+}
+
+func TestReconcileScaleRecommended(t *testing.T) {
+	autoscaler := syncedAutoscaler(100)
+	autoscaler.Spec.Mode = bigtablev2.RecommendAutoscalerMode
+
+	bigtableClient := &mocks.BigtableClient{}
+	r, recorder := newTestReconciler(t, dialBigtable(bigtableClient), autoscaler)
+
+	reconcileAutoscaler(t, r)
+	reconciled := reconcileAutoscaler(t, r)
+
+	bigtableClient.AssertNotCalled(t, "UpdateCluster", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, []string{"Normal ScaleRecommended"}, eventReasons(recorder))
+	assert.Equal(t, int32(8), *reconciled.Status.RecommendedNodes)
+}
+
+func TestIndexSecretRef(t *testing.T) {
+	tests := map[string]struct {
+		secretRef *bigtablev2.ServiceAccountSecretRef
+		expected  []string
+	}{
+		"no secret": {},
+		"secret without name": {
+			secretRef: &bigtablev2.ServiceAccountSecretRef{Key: pointer.String("service-account")},
+		},
+		"secret in the namespace of the autoscaler": {
+			secretRef: &bigtablev2.ServiceAccountSecretRef{Name: pointer.String("credentials")},
+			expected:  []string{"default/credentials"},
+		},
+		"secret in another namespace": {
+			secretRef: &bigtablev2.ServiceAccountSecretRef{
+				Name:      pointer.String("credentials"),
+				Namespace: pointer.String("shared"),
+			},
+			expected: []string{"shared/credentials"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			autoscaler := testAutoscaler()
+			autoscaler.Spec.ServiceAccountSecretRef = test.secretRef
+
+			assert.Equal(t, test.expected, indexSecretRef(autoscaler))
+		})
+	}
+}
+
+// indexedClient serves the field selectors on secretRefIndex, which the fake client ignores.
+type indexedClient struct {
+	ctrlclient.Client
+}
+
+func (c indexedClient) List(ctx context.Context, list runtime.Object, opts ...ctrlclient.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+
+	listOpts := ctrlclient.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector == nil {
+		return nil
+	}
+
+	secret, ok := listOpts.FieldSelector.RequiresExactMatch(secretRefIndex)
+	autoscalers, isAutoscalers := list.(*bigtablev2.BigtableAutoscalerList)
+	if !ok || !isAutoscalers {
+		return nil
+	}
+
+	var items []bigtablev2.BigtableAutoscaler
+	for _, autoscaler := range autoscalers.Items {
+		autoscaler := autoscaler
+		if keys := indexSecretRef(&autoscaler); len(keys) == 1 && keys[0] == secret {
+			items = append(items, autoscaler)
+		}
+	}
+	autoscalers.Items = items
+
+	return nil
+}
+
+func TestAutoscalersForSecret(t *testing.T) {
+	withSecret := func(name, secretName string, secretNamespace *string) *bigtablev2.BigtableAutoscaler {
+		autoscaler := testAutoscaler()
+		autoscaler.Name = name
+		autoscaler.Spec.ServiceAccountSecretRef = &bigtablev2.ServiceAccountSecretRef{
+			Name:      pointer.String(secretName),
+			Namespace: secretNamespace,
+			Key:       pointer.String("service-account"),
+		}
+
+		return autoscaler
+	}
+
+	r, _ := newTestReconciler(t, nil,
+		withSecret("reads-credentials", "credentials", nil),
+		withSecret("reads-shared-credentials", "credentials", pointer.String("shared")),
+		withSecret("reads-other-credentials", "other-credentials", nil),
+		testAutoscaler(),
+	)
+	r.Client = indexedClient{r.Client}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"}}
+	requests := r.autoscalersForSecret(handler.MapObject{Meta: secret, Object: secret})
+
+	assert.Equal(t, []reconcile.Request{
+		{NamespacedName: ctrlclient.ObjectKey{Namespace: "default", Name: "reads-credentials"}},
+	}, requests)
+}
+
+func TestReconcileCredentialsMissing(t *testing.T) {
+	autoscaler := testAutoscaler()
+	autoscaler.Spec.ServiceAccountSecretRef = &bigtablev2.ServiceAccountSecretRef{
+		Name: pointer.String("credentials"),
+		Key:  pointer.String("service-account"),
+	}
+
+	r, recorder := newTestReconciler(t, nil, autoscaler)
+
+	reconcileAutoscaler(t, r)
+	reconciled := reconcileAutoscaler(t, r)
+
+	assert.Equal(t, []string{"Warning CredentialsMissing"}, eventReasons(recorder))
+
+	valid := conditions.Find(reconciled.Status.Conditions, bigtablev2.ConditionCredentialsValid)
+	if assert.NotNil(t, valid) {
+		assert.Equal(t, metav1.ConditionFalse, valid.Status)
+		assert.Equal(t, string(googlecloud.SecretNotFoundReason), valid.Reason)
+	}
+}
+
+func TestReconcileCredentialsMissingStopsSync(t *testing.T) {
+	bigtableClient := &mocks.BigtableClient{}
+	r, _ := newTestReconciler(t, dialBigtable(bigtableClient), testAutoscaler())
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: autoscalerKey})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.clients.Size())
+
+	var autoscaler bigtablev2.BigtableAutoscaler
+	assert.NoError(t, r.Get(context.Background(), autoscalerKey, &autoscaler))
+	autoscaler.Spec.ServiceAccountSecretRef = &bigtablev2.ServiceAccountSecretRef{
+		Name: pointer.String("credentials"),
+		Key:  pointer.String("service-account"),
+	}
+	assert.NoError(t, r.Update(context.Background(), &autoscaler))
+
+	reconcileAutoscaler(t, r)
+
+	assert.False(t, r.syncer.Unregister(autoscaler.UID))
+	assert.Equal(t, 0, r.clients.Size())
+	bigtableClient.AssertCalled(t, "Close")
+}
+
+func TestReconcileUnknownStrategy(t *testing.T) {
+	autoscaler := syncedAutoscaler(100)
+	autoscaler.Spec.Strategy = "Unknown"
+
+	bigtableClient := &mocks.BigtableClient{}
+	r, recorder := newTestReconciler(t, dialBigtable(bigtableClient), autoscaler)
+
+	reconcileAutoscaler(t, r)
+	reconciled := reconcileAutoscaler(t, r)
+
+	bigtableClient.AssertNotCalled(t, "UpdateCluster", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, []string{"Warning UnknownStrategy"}, eventReasons(recorder))
+
+	active := conditions.Find(reconciled.Status.Conditions, bigtablev2.ConditionScalingActive)
+	if assert.NotNil(t, active) {
+		assert.Equal(t, metav1.ConditionFalse, active.Status)
+		assert.Equal(t, "UnknownStrategy", active.Reason)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/nodes_calculator"
+)
+
+// BigtableCapacityReservationReconciler reconciles a BigtableCapacityReservation object. The
+// reservations are applied by the autoscaler controller; this one tracks their phase and deletes
+// them once they are over.
+type BigtableCapacityReservationReconciler struct {
+	ctrlclient.Client
+
+	clock clock.Clock
+	log   logr.Logger
+}
+
+func NewBigtableCapacityReservationReconciler(client ctrlclient.Client) *BigtableCapacityReservationReconciler {
+	return &BigtableCapacityReservationReconciler{
+		Client: client,
+		clock:  clock.RealClock{},
+		log:    ctrl.Log.WithName("controllers").WithName("BigtableCapacityReservation"),
+	}
+}
+
+func (r *BigtableCapacityReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&bigtablev2.BigtableCapacityReservation{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=bigtable.bigtable-autoscaler.com,resources=bigtablecapacityreservations,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=bigtable.bigtable-autoscaler.com,resources=bigtablecapacityreservations/status,verbs=get;update;patch
+func (r *BigtableCapacityReservationReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+
+	var reservation bigtablev2.BigtableCapacityReservation
+	if err := r.Get(ctx, req.NamespacedName, &reservation); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("failed to get capacity reservation: %w", err)
+	}
+
+	now := r.clock.Now()
+
+	phase := nodes_calculator.ReservationPhase(&reservation, now)
+	if phase == "" {
+		r.log.Info("Deleting expired capacity reservation", "reservation", req.NamespacedName)
+
+		if err := r.Delete(ctx, &reservation); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("failed to delete expired capacity reservation: %w", err)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if reservation.Status.Phase != phase {
+		reservation.Status.Phase = phase
+		if err := r.Status().Update(ctx, &reservation); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update capacity reservation status: %w", err)
+		}
+	}
+
+	next, _ := nodes_calculator.ReservationNextTransition(&reservation, now)
+
+	return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+}
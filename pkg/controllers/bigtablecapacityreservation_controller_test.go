@@ -0,0 +1,146 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+var reservationKey = ctrlclient.ObjectKey{Namespace: "default", Name: "black-friday"}
+
+// testReservation returns a reservation of 12 nodes starting 2 hours after testNow, ramped up
+// over the hour before, and lasting a day.
+func testReservation() *bigtablev2.BigtableCapacityReservation {
+	start := testNow.Add(2 * time.Hour)
+
+	return &bigtablev2.BigtableCapacityReservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      reservationKey.Name,
+			Namespace: reservationKey.Namespace,
+		},
+		Spec: bigtablev2.BigtableCapacityReservationSpec{
+			AutoscalerRef:  corev1.LocalObjectReference{Name: autoscalerKey.Name},
+			Nodes:          12,
+			StartTime:      metav1.NewTime(start),
+			EndTime:        metav1.NewTime(start.Add(24 * time.Hour)),
+			RampUpLeadTime: &metav1.Duration{Duration: time.Hour},
+		},
+	}
+}
+
+// newTestReservationReconciler returns a reconciler reading the objects from a fake client, at now.
+func newTestReservationReconciler(t *testing.T, now time.Time, objects ...runtime.Object) *BigtableCapacityReservationReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, bigtablev2.AddToScheme(scheme))
+
+	return &BigtableCapacityReservationReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, objects...),
+		clock:  clocktesting.NewFakeClock(now),
+		log:    ctrl.Log.WithName("test"),
+	}
+}
+
+func TestReconcileReservationPhase(t *testing.T) {
+	tests := map[string]struct {
+		now             time.Time
+		expectedPhase   bigtablev2.CapacityReservationPhase
+		expectedRequeue time.Duration
+	}{
+		"pending": {
+			now:             testNow,
+			expectedPhase:   bigtablev2.PendingCapacityReservationPhase,
+			expectedRequeue: time.Hour,
+		},
+		"ramping up": {
+			now:             testNow.Add(90 * time.Minute),
+			expectedPhase:   bigtablev2.RampingUpCapacityReservationPhase,
+			expectedRequeue: 30 * time.Minute,
+		},
+		"active": {
+			now:             testNow.Add(2 * time.Hour),
+			expectedPhase:   bigtablev2.ActiveCapacityReservationPhase,
+			expectedRequeue: 24 * time.Hour,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := newTestReservationReconciler(t, test.now, testReservation())
+
+			result, err := r.Reconcile(ctrl.Request{NamespacedName: reservationKey})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedRequeue, result.RequeueAfter)
+
+			var reservation bigtablev2.BigtableCapacityReservation
+			assert.NoError(t, r.Get(context.Background(), reservationKey, &reservation))
+			assert.Equal(t, test.expectedPhase, reservation.Status.Phase)
+		})
+	}
+}
+
+func TestReconcileReservationTransitions(t *testing.T) {
+	r := newTestReservationReconciler(t, testNow, testReservation())
+	clock := r.clock.(*clocktesting.FakeClock)
+
+	var phases []bigtablev2.CapacityReservationPhase
+	for i := 0; i < 3; i++ {
+		result, err := r.Reconcile(ctrl.Request{NamespacedName: reservationKey})
+		assert.NoError(t, err)
+
+		var reservation bigtablev2.BigtableCapacityReservation
+		assert.NoError(t, r.Get(context.Background(), reservationKey, &reservation))
+		phases = append(phases, reservation.Status.Phase)
+
+		clock.Step(result.RequeueAfter)
+	}
+
+	assert.Equal(t, []bigtablev2.CapacityReservationPhase{
+		bigtablev2.PendingCapacityReservationPhase,
+		bigtablev2.RampingUpCapacityReservationPhase,
+		bigtablev2.ActiveCapacityReservationPhase,
+	}, phases)
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: reservationKey})
+	assert.NoError(t, err)
+	assert.True(t, errors.IsNotFound(r.Get(context.Background(), reservationKey, &bigtablev2.BigtableCapacityReservation{})))
+}
+
+func TestReconcileExpiredReservation(t *testing.T) {
+	r := newTestReservationReconciler(t, testNow.Add(26*time.Hour), testReservation())
+
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: reservationKey})
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+
+	var reservation bigtablev2.BigtableCapacityReservation
+	err = r.Get(context.Background(), reservationKey, &reservation)
+	assert.True(t, errors.IsNotFound(err), "expected the reservation to be deleted, got %v", err)
+}
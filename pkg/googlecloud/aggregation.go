@@ -0,0 +1,145 @@
+package googlecloud
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+)
+
+// Aggregation is how the values of a metric within the window are combined.
+type Aggregation string
+
+const (
+	// AggregationLatest uses the latest value of the metric.
+	AggregationLatest Aggregation = "Latest"
+
+	// AggregationMean uses the mean of the values within the window.
+	AggregationMean Aggregation = "Mean"
+
+	// AggregationMax uses the highest value within the window.
+	AggregationMax Aggregation = "Max"
+
+	// AggregationP95 uses the 95th percentile of the values within the window.
+	AggregationP95 Aggregation = "P95"
+)
+
+const (
+	// DefaultWindow is how far back metrics are read when QueryOptions.Window is not set.
+	DefaultWindow = 5 * time.Minute
+
+	// DefaultAlignmentPeriod is the period the points of each time series are aligned to
+	// when QueryOptions.AlignmentPeriod is not set.
+	DefaultAlignmentPeriod = 1 * time.Minute
+)
+
+// QueryOptions configures how a metric is read.
+type QueryOptions struct {
+	// Aggregation defaults to AggregationLatest.
+	Aggregation Aggregation
+
+	// Window defaults to DefaultWindow.
+	Window time.Duration
+
+	// AlignmentPeriod defaults to DefaultAlignmentPeriod.
+	AlignmentPeriod time.Duration
+
+	// Rate reads the rate of change per second of the metric instead of its values, for DELTA and
+	// CUMULATIVE metrics, which Cloud Monitoring can't align otherwise.
+	Rate bool
+
+	// sumSeries sums the time series matched by the filter instead of averaging them.
+	sumSeries bool
+}
+
+func (o QueryOptions) window() time.Duration {
+	if o.Window <= 0 {
+		return DefaultWindow
+	}
+
+	return o.Window
+}
+
+func (o QueryOptions) alignmentPeriod() time.Duration {
+	if o.AlignmentPeriod <= 0 {
+		return DefaultAlignmentPeriod
+	}
+
+	return o.AlignmentPeriod
+}
+
+// monitoringAggregation returns the aligner and reducer used to read the metric. Each time series
+// is aligned to the alignment period and the series matched by the filter are reduced to one, so that
+// the returned points only need to be combined over the window.
+func (o QueryOptions) monitoringAggregation() *monitoringpb.Aggregation {
+	var aligner monitoringpb.Aggregation_Aligner
+	var reducer monitoringpb.Aggregation_Reducer
+
+	switch o.Aggregation {
+	case AggregationMean:
+		aligner = monitoringpb.Aggregation_ALIGN_MEAN
+		reducer = monitoringpb.Aggregation_REDUCE_MEAN
+	case AggregationMax:
+		aligner = monitoringpb.Aggregation_ALIGN_MAX
+		reducer = monitoringpb.Aggregation_REDUCE_MAX
+	case AggregationP95:
+		aligner = monitoringpb.Aggregation_ALIGN_PERCENTILE_95
+		reducer = monitoringpb.Aggregation_REDUCE_PERCENTILE_95
+	default:
+		aligner = monitoringpb.Aggregation_ALIGN_NEXT_OLDER
+		reducer = monitoringpb.Aggregation_REDUCE_MEAN
+	}
+
+	if o.Rate {
+		aligner = monitoringpb.Aggregation_ALIGN_RATE
+	}
+
+	if o.sumSeries {
+		reducer = monitoringpb.Aggregation_REDUCE_SUM
+	}
+
+	return &monitoringpb.Aggregation{
+		AlignmentPeriod:    &duration.Duration{Seconds: int64(o.alignmentPeriod() / time.Second)},
+		PerSeriesAligner:   aligner,
+		CrossSeriesReducer: reducer,
+	}
+}
+
+// aggregate combines the aligned values of the window, newest first, into one.
+func (o QueryOptions) aggregate(values []float64) (float64, error) {
+	if len(values) == 0 {
+		return 0, fmt.Errorf("no values to aggregate")
+	}
+
+	switch o.Aggregation {
+	case AggregationMean:
+		var sum float64
+		for _, value := range values {
+			sum += value
+		}
+
+		return sum / float64(len(values)), nil
+	case AggregationMax:
+		max := values[0]
+		for _, value := range values[1:] {
+			max = math.Max(max, value)
+		}
+
+		return max, nil
+	case AggregationP95:
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+
+		// nearest-rank percentile
+		rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+
+		return sorted[rank], nil
+	case AggregationLatest, "":
+		return values[0], nil
+	default:
+		return 0, fmt.Errorf("unsupported aggregation %q", o.Aggregation)
+	}
+}
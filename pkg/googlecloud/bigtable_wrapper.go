@@ -43,3 +43,11 @@ func (b *bigtableClientWrapper) Clusters(
 
 	return clustersInfoWrapped, nil
 }
+
+func (b *bigtableClientWrapper) UpdateCluster(ctx context.Context, instanceID, clusterID string, serveNodes int32) error {
+	return b.bigtableClient.UpdateCluster(ctx, instanceID, clusterID, serveNodes)
+}
+
+func (b *bigtableClientWrapper) Close() error {
+	return b.bigtableClient.Close()
+}
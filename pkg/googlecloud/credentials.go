@@ -0,0 +1,103 @@
+package googlecloud
+
+import (
+	"encoding/json"
+
+	"google.golang.org/api/option"
+)
+
+// CredentialsSource tells where the credentials of the Google Cloud clients come from.
+type CredentialsSource string
+
+const (
+	// SecretCredentialsSource is a service account key read from a Secret.
+	SecretCredentialsSource CredentialsSource = "Secret"
+
+	// ApplicationDefaultCredentialsSource is Application Default Credentials, e.g. GKE
+	// Workload Identity or the metadata server.
+	ApplicationDefaultCredentialsSource CredentialsSource = "ApplicationDefault"
+)
+
+// CredentialsErrorReason tells why the credentials of an autoscaler could not be loaded.
+type CredentialsErrorReason string
+
+const (
+	// SecretNotFoundReason is a referenced Secret that does not exist.
+	SecretNotFoundReason CredentialsErrorReason = "SecretNotFound"
+
+	// KeyMissingReason is a referenced Secret without the referenced key, or with an empty one.
+	KeyMissingReason CredentialsErrorReason = "KeyMissing"
+
+	// InvalidJSONReason is a service account key that is not a Google credentials JSON file.
+	InvalidJSONReason CredentialsErrorReason = "InvalidJSON"
+)
+
+// CredentialsError is an error in the configuration of the credentials, which retrying won't fix
+// until the Secret or the autoscaler is changed.
+type CredentialsError struct {
+	Reason  CredentialsErrorReason
+	Message string
+}
+
+func (e *CredentialsError) Error() string {
+	return e.Message
+}
+
+// Credentials authenticate the Google Cloud clients.
+type Credentials struct {
+	// JSON is a service account key. When empty, Application Default Credentials are used.
+	JSON []byte
+
+	// ImpersonateServiceAccount is the email of a service account the clients act as, using
+	// the credentials above to impersonate it. When empty, no service account is impersonated.
+	ImpersonateServiceAccount string
+
+	// Delegates is the delegation chain from the credentials above to ImpersonateServiceAccount.
+	Delegates []string
+}
+
+// Source tells where the credentials come from.
+func (c Credentials) Source() CredentialsSource {
+	if len(c.JSON) == 0 {
+		return ApplicationDefaultCredentialsSource
+	}
+
+	return SecretCredentialsSource
+}
+
+// ClientOptions returns the options authenticating a Google Cloud client with the credentials.
+func (c Credentials) ClientOptions() []option.ClientOption {
+	var options []option.ClientOption
+
+	if len(c.JSON) != 0 {
+		options = append(options, option.WithCredentialsJSON(c.JSON))
+	}
+
+	if c.ImpersonateServiceAccount != "" {
+		options = append(options, option.ImpersonateCredentials(c.ImpersonateServiceAccount, c.Delegates...))
+	}
+
+	return options
+}
+
+// Validate checks that the service account key, if any, is a Google credentials JSON file, so that
+// a malformed key is reported as such rather than by the construction of the clients.
+func (c Credentials) Validate() error {
+	if len(c.JSON) == 0 {
+		return nil
+	}
+
+	var file struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(c.JSON, &file); err != nil {
+		return &CredentialsError{Reason: InvalidJSONReason, Message: "invalid credentials JSON: " + err.Error()}
+	}
+
+	if file.Type == "" {
+		return &CredentialsError{Reason: InvalidJSONReason, Message: "invalid credentials JSON: missing \"type\" field"}
+	}
+
+	return nil
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"cloud.google.com/go/bigtable"
 	monitoring "cloud.google.com/go/monitoring/apiv3"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"google.golang.org/api/iterator"
-	"google.golang.org/api/option"
 
 	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
 )
@@ -20,48 +20,129 @@ type googleCloudClient struct {
 	bigtableClient BigtableClient
 	projectID      string
 	instanceID     string
+	clusterID      string
 	ctx            context.Context
 }
 
-func NewClientFromCredentials(ctx context.Context, credentialsJSON []byte, projectID, instanceID string) (GoogleCloudClient, error) {
-	metricClient, err := monitoring.NewMetricClient(ctx, option.WithCredentialsJSON(credentialsJSON))
+// dialClients dials the clients of a project, authenticated with the given credentials.
+func dialClients(ctx context.Context, credentials Credentials, projectID string) (MetricClient, BigtableClient, error) {
+	metricClient, err := monitoring.NewMetricClient(ctx, credentials.ClientOptions()...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create metrics client: %w", err)
-	}
-	metricClientWrapped := metricClientWrapper{
-		metricsClient: metricClient,
+		return nil, nil, fmt.Errorf("failed to create metrics client: %w", err)
 	}
 
-	bigtableClient, err := bigtable.NewInstanceAdminClient(ctx, projectID, option.WithCredentialsJSON(credentialsJSON))
+	bigtableClient, err := bigtable.NewInstanceAdminClient(ctx, projectID, credentials.ClientOptions()...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create bigtable client: %w", err)
-	}
-	bigtableClientWrapped := bigtableClientWrapper{
-		bigtableClient: bigtableClient,
+		metricClient.Close()
+
+		return nil, nil, fmt.Errorf("failed to create bigtable client: %w", err)
 	}
 
-	return NewClient(ctx, projectID, instanceID, &metricClientWrapped, &bigtableClientWrapped), nil
+	return &metricClientWrapper{metricsClient: metricClient}, &bigtableClientWrapper{bigtableClient: bigtableClient}, nil
 }
 
-func NewClient(ctx context.Context, projectID, instanceID string, metricClientWrapped MetricClient,
+func NewClient(ctx context.Context, projectID, instanceID, clusterID string, metricClientWrapped MetricClient,
 	bigtableClientWrapped BigtableClient) GoogleCloudClient {
 	return &googleCloudClient{
 		metricsClient:  metricClientWrapped,
 		bigtableClient: bigtableClientWrapped,
 		projectID:      projectID,
 		instanceID:     instanceID,
+		clusterID:      clusterID,
 		ctx:            ctx,
 	}
 }
 
-func (m *googleCloudClient) GetCurrentCPULoad() (int32, error) {
-	const timeWindow = 5 * time.Minute
+const (
+	cpuLoadMetricType            = "bigtable.googleapis.com/cluster/cpu_load"
+	cpuLoadHottestNodeMetricType = "bigtable.googleapis.com/cluster/cpu_load_hottest_node"
+	storageUtilizationMetricType = "bigtable.googleapis.com/cluster/storage_utilization"
+)
+
+func (m *googleCloudClient) GetCurrentCPULoad(opts QueryOptions) (int32, error) {
+	return m.getCurrentUtilization(cpuLoadMetricType, opts, math.Floor)
+}
+
+func (m *googleCloudClient) GetCurrentHottestNodeCPULoad(opts QueryOptions) (int32, error) {
+	return m.getCurrentUtilization(cpuLoadHottestNodeMetricType, opts, math.Floor)
+}
+
+// GetCurrentStorageUtilization rounds the utilization up, so the storage floor is never computed
+// from a utilization lower than the actual one.
+func (m *googleCloudClient) GetCurrentStorageUtilization(opts QueryOptions) (int32, error) {
+	return m.getCurrentUtilization(storageUtilizationMetricType, opts, math.Ceil)
+}
+
+// GetCurrentMetricValue returns the value of an arbitrary Cloud Monitoring metric, summed over the
+// time series matched by the filter. The filter, when not empty, is combined with the metric type
+// using AND.
+func (m *googleCloudClient) GetCurrentMetricValue(metricType, filter string, opts QueryOptions) (float64, error) {
+	opts.sumSeries = true
+
+	return m.getCurrentValue(metricType, filter, opts)
+}
+
+// getCurrentUtilization reads a Bigtable utilization metric of the cluster, in percent, rounded
+// to a whole percent with round.
+func (m *googleCloudClient) getCurrentUtilization(metricType string, opts QueryOptions, round func(float64) float64) (int32, error) {
+	const percent float64 = 100
+
+	// tolerance absorbs the floating point error of the conversion to percent, e.g. 0.29 * 100
+	// is 28.999999999999996 and 0.57 * 100 is 56.99999999999999.
+	const tolerance float64 = 1e-9
+
+	clusterFilter := fmt.Sprintf(`resource.labels.instance="%s" AND resource.labels.cluster="%s"`, m.instanceID, m.clusterID)
+
+	value, err := m.getCurrentValue(metricType, clusterFilter, opts)
+	if err != nil {
+		return -1, err
+	}
+
+	value *= percent
+	if nearest := math.Round(value); math.Abs(value-nearest) < tolerance {
+		value = nearest
+	}
+
+	return int32(round(value)), nil
+}
+
+func (m *googleCloudClient) getCurrentValue(metricType, filter string, opts QueryOptions) (float64, error) {
+	it := m.metricsClient.ListTimeSeries(m.ctx, m.newTimeSeriesRequest(metricType, filter, opts))
+
+	for {
+		values, err := it.Values()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return -1, fmt.Errorf("failed get values from time series: %w", err)
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		value, err := opts.aggregate(values)
+		if err != nil {
+			return -1, fmt.Errorf("failed to aggregate values of metric %s: %w", metricType, err)
+		}
+
+		return value, nil
+	}
+	return -1, fmt.Errorf("no data found for metric %s", metricType)
+}
 
-	startTime := time.Now().UTC().Add(-timeWindow)
+func (m *googleCloudClient) newTimeSeriesRequest(metricType, filter string, opts QueryOptions) *monitoringpb.ListTimeSeriesRequest {
 	endTime := time.Now().UTC()
-	request := &monitoringpb.ListTimeSeriesRequest{
+	startTime := endTime.Add(-opts.window())
+
+	fullFilter := fmt.Sprintf(`metric.type="%s"`, metricType)
+	if filter != "" {
+		fullFilter = fmt.Sprintf("%s AND (%s)", fullFilter, filter)
+	}
+
+	return &monitoringpb.ListTimeSeriesRequest{
 		Name:   "projects/" + m.projectID,
-		Filter: `metric.type="bigtable.googleapis.com/cluster/cpu_load"`,
+		Filter: fullFilter,
 		Interval: &monitoringpb.TimeInterval{
 			StartTime: &timestamp.Timestamp{
 				Seconds: startTime.Unix(),
@@ -70,21 +151,8 @@ func (m *googleCloudClient) GetCurrentCPULoad() (int32, error) {
 				Seconds: endTime.Unix(),
 			},
 		},
+		Aggregation: opts.monitoringAggregation(),
 	}
-
-	it := m.metricsClient.ListTimeSeries(m.ctx, request)
-
-	for {
-		points, err := it.Points()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return -1, fmt.Errorf("failed get points data from time series: %w", err)
-		}
-		return points[0], nil
-	}
-	return -1, nil
 }
 
 func (m *googleCloudClient) GetCurrentNodeCount(clusterID string) (int32, error) {
@@ -101,3 +169,11 @@ func (m *googleCloudClient) GetCurrentNodeCount(clusterID string) (int32, error)
 	message := fmt.Sprintf("Cluster of id %s not found", clusterID)
 	return -1, errors.New(message)
 }
+
+func (m *googleCloudClient) UpdateNodeCount(clusterID string, nodes int32) error {
+	if err := m.bigtableClient.UpdateCluster(m.ctx, m.instanceID, clusterID, nodes); err != nil {
+		return fmt.Errorf("failed to update cluster %s: %w", clusterID, err)
+	}
+
+	return nil
+}
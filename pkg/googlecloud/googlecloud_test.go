@@ -4,23 +4,28 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"bigtable-autoscaler.com/m/v2/pkg/googlecloud"
 
 	"bigtable-autoscaler.com/m/v2/mocks"
 	"github.com/stretchr/testify/mock"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
 )
 
 func Test_googleCloudClient_GetCurrentCPULoad(t *testing.T) {
 	mockMetricsClient := mocks.MetricClient{}
 	mockTimeSeriesIterator := mocks.TimeSeriesIterator{}
-	values := []int32{50, 45, 30}
-	mockTimeSeriesIterator.On("Points").Return(values, nil)
-	mockMetricsClient.On("ListTimeSeries", mock.Anything, mock.Anything).Return(&mockTimeSeriesIterator)
+	values := []float64{0.5, 0.45, 0.3}
+	mockTimeSeriesIterator.On("Values").Return(values, nil)
+	mockMetricsClient.On("ListTimeSeries", mock.Anything, mock.MatchedBy(func(req *monitoringpb.ListTimeSeriesRequest) bool {
+		return req.Filter == `metric.type="bigtable.googleapis.com/cluster/cpu_load" AND `+
+			`(resource.labels.instance="my-instance-id" AND resource.labels.cluster="my-cluster-id")`
+	})).Return(&mockTimeSeriesIterator)
 
 	mockMetricsClientError := mocks.MetricClient{}
 	mockTimeSeriesIteratorError := mocks.TimeSeriesIterator{}
-	mockTimeSeriesIteratorError.On("Points").Return(nil, errors.New("failed to get metrics"))
+	mockTimeSeriesIteratorError.On("Values").Return(nil, errors.New("failed to get metrics"))
 	mockMetricsClientError.On("ListTimeSeries", mock.Anything, mock.Anything).
 		Return(&mockTimeSeriesIteratorError)
 
@@ -28,13 +33,15 @@ func Test_googleCloudClient_GetCurrentCPULoad(t *testing.T) {
 		metricsClient googlecloud.MetricClient
 		projectID     string
 		instanceID    string
+		clusterID     string
 		ctx           context.Context
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		want    int32
-		wantErr bool
+		name        string
+		fields      fields
+		aggregation googlecloud.Aggregation
+		want        int32
+		wantErr     bool
 	}{
 		{
 			name: "returns the first value of the series",
@@ -42,10 +49,38 @@ func Test_googleCloudClient_GetCurrentCPULoad(t *testing.T) {
 				metricsClient: &mockMetricsClient,
 				projectID:     "my-project-id",
 				instanceID:    "my-instance-id",
+				clusterID:     "my-cluster-id",
 				ctx:           context.Background(),
 			},
-			want:    50,
-			wantErr: false,
+			aggregation: googlecloud.AggregationLatest,
+			want:        50,
+			wantErr:     false,
+		},
+		{
+			name: "returns the mean of the series",
+			fields: fields{
+				metricsClient: &mockMetricsClient,
+				projectID:     "my-project-id",
+				instanceID:    "my-instance-id",
+				clusterID:     "my-cluster-id",
+				ctx:           context.Background(),
+			},
+			aggregation: googlecloud.AggregationMean,
+			want:        41,
+			wantErr:     false,
+		},
+		{
+			name: "returns the max of the series",
+			fields: fields{
+				metricsClient: &mockMetricsClient,
+				projectID:     "my-project-id",
+				instanceID:    "my-instance-id",
+				clusterID:     "my-cluster-id",
+				ctx:           context.Background(),
+			},
+			aggregation: googlecloud.AggregationMax,
+			want:        50,
+			wantErr:     false,
 		},
 		{
 			name: "raises error",
@@ -53,6 +88,7 @@ func Test_googleCloudClient_GetCurrentCPULoad(t *testing.T) {
 				metricsClient: &mockMetricsClientError,
 				projectID:     "my-project-id",
 				instanceID:    "my-instance-id",
+				clusterID:     "my-cluster-id",
 				ctx:           context.Background(),
 			},
 			want:    -1,
@@ -65,10 +101,11 @@ func Test_googleCloudClient_GetCurrentCPULoad(t *testing.T) {
 				tt.fields.ctx,
 				tt.fields.projectID,
 				tt.fields.instanceID,
+				tt.fields.clusterID,
 				tt.fields.metricsClient,
 				nil,
 			)
-			got, err := m.GetCurrentCPULoad()
+			got, err := m.GetCurrentCPULoad(googlecloud.QueryOptions{Aggregation: tt.aggregation})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("googleCloudClient.GetMetrics() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -81,6 +118,49 @@ func Test_googleCloudClient_GetCurrentCPULoad(t *testing.T) {
 	}
 }
 
+func Test_googleCloudClient_GetCurrentStorageUtilization(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		want  int32
+	}{
+		{name: "rounds up a fraction of a percent", value: 0.7001, want: 71},
+		{name: "rounds up just below a percent", value: 0.6999, want: 70},
+		{name: "keeps a whole percent", value: 0.7, want: 70},
+		{name: "keeps a whole percent with floating point error", value: 0.29, want: 29},
+		{name: "keeps zero", value: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMetricsClient := mocks.MetricClient{}
+			mockTimeSeriesIterator := mocks.TimeSeriesIterator{}
+			mockTimeSeriesIterator.On("Values").Return([]float64{tt.value}, nil)
+			mockMetricsClient.On("ListTimeSeries", mock.Anything, mock.MatchedBy(func(req *monitoringpb.ListTimeSeriesRequest) bool {
+				return req.Filter == `metric.type="bigtable.googleapis.com/cluster/storage_utilization" AND `+
+					`(resource.labels.instance="my-instance-id" AND resource.labels.cluster="my-cluster-id")`
+			})).Return(&mockTimeSeriesIterator)
+
+			m := googlecloud.NewClient(
+				context.Background(),
+				"my-project-id",
+				"my-instance-id",
+				"my-cluster-id",
+				&mockMetricsClient,
+				nil,
+			)
+			got, err := m.GetCurrentStorageUtilization(googlecloud.QueryOptions{Aggregation: googlecloud.AggregationLatest})
+			if err != nil {
+				t.Errorf("googleCloudClient.GetCurrentStorageUtilization() error = %v", err)
+
+				return
+			}
+			if got != tt.want {
+				t.Errorf("googleCloudClient.GetCurrentStorageUtilization() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_googleCloudClient_GetCurrentNodeCount(t *testing.T) {
 	mockBigtableClient := mocks.BigtableClient{}
 	mockClusterInfo := mocks.ClusterInfo{}
@@ -137,6 +217,7 @@ func Test_googleCloudClient_GetCurrentNodeCount(t *testing.T) {
 				tt.fields.ctx,
 				tt.fields.projectID,
 				tt.fields.instanceID,
+				tt.fields.clusterID,
 				nil,
 				tt.fields.bigtableClient,
 			)
@@ -152,3 +233,272 @@ func Test_googleCloudClient_GetCurrentNodeCount(t *testing.T) {
 		})
 	}
 }
+
+func Test_googleCloudClient_GetCurrentMetricValue(t *testing.T) {
+	mockMetricsClient := mocks.MetricClient{}
+	mockTimeSeriesIterator := mocks.TimeSeriesIterator{}
+	mockTimeSeriesIterator.On("Values").Return([]float64{1250, 900}, nil)
+	mockMetricsClient.On("ListTimeSeries", mock.Anything, mock.MatchedBy(func(req *monitoringpb.ListTimeSeriesRequest) bool {
+		return req.Filter == `metric.type="custom.googleapis.com/queue_size" AND (resource.labels.queue="jobs")`
+	})).Return(&mockTimeSeriesIterator)
+
+	mockMetricsClientError := mocks.MetricClient{}
+	mockTimeSeriesIteratorError := mocks.TimeSeriesIterator{}
+	mockTimeSeriesIteratorError.On("Values").Return(nil, errors.New("failed to get metrics"))
+	mockMetricsClientError.On("ListTimeSeries", mock.Anything, mock.Anything).
+		Return(&mockTimeSeriesIteratorError)
+
+	tests := []struct {
+		name          string
+		metricsClient googlecloud.MetricClient
+		want          float64
+		wantErr       bool
+	}{
+		{
+			name:          "returns the first value of the series",
+			metricsClient: &mockMetricsClient,
+			want:          1250,
+			wantErr:       false,
+		},
+		{
+			name:          "raises error",
+			metricsClient: &mockMetricsClientError,
+			want:          -1,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := googlecloud.NewClient(context.Background(), "my-project-id", "my-instance-id", "my-cluster-id", tt.metricsClient, nil)
+			got, err := m.GetCurrentMetricValue("custom.googleapis.com/queue_size", `resource.labels.queue="jobs"`, googlecloud.QueryOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("googleCloudClient.GetCurrentMetricValue() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if got != tt.want {
+				t.Errorf("googleCloudClient.GetCurrentMetricValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_googleCloudClient_QueryWindow(t *testing.T) {
+	tests := []struct {
+		name                string
+		opts                googlecloud.QueryOptions
+		wantWindow          int64
+		wantAlignmentPeriod int64
+	}{
+		{
+			name:                "uses the default window and alignment period",
+			opts:                googlecloud.QueryOptions{},
+			wantWindow:          300,
+			wantAlignmentPeriod: 60,
+		},
+		{
+			name:                "uses the given window and alignment period",
+			opts:                googlecloud.QueryOptions{Window: 30 * time.Minute, AlignmentPeriod: 5 * time.Minute},
+			wantWindow:          1800,
+			wantAlignmentPeriod: 300,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMetricsClient := mocks.MetricClient{}
+			mockTimeSeriesIterator := mocks.TimeSeriesIterator{}
+			mockTimeSeriesIterator.On("Values").Return([]float64{0.5}, nil)
+			mockMetricsClient.On("ListTimeSeries", mock.Anything, mock.MatchedBy(func(req *monitoringpb.ListTimeSeriesRequest) bool {
+				window := req.Interval.EndTime.Seconds - req.Interval.StartTime.Seconds
+
+				return window == tt.wantWindow && req.Aggregation.AlignmentPeriod.Seconds == tt.wantAlignmentPeriod
+			})).Return(&mockTimeSeriesIterator)
+
+			m := googlecloud.NewClient(context.Background(), "my-project-id", "my-instance-id", "my-cluster-id", &mockMetricsClient, nil)
+			if _, err := m.GetCurrentCPULoad(tt.opts); err != nil {
+				t.Errorf("googleCloudClient.GetCurrentCPULoad() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_googleCloudClient_QueryAggregation(t *testing.T) {
+	readCPULoad := func(m googlecloud.GoogleCloudClient, opts googlecloud.QueryOptions) error {
+		_, err := m.GetCurrentCPULoad(opts)
+		return err
+	}
+	readMetricValue := func(m googlecloud.GoogleCloudClient, opts googlecloud.QueryOptions) error {
+		_, err := m.GetCurrentMetricValue("custom.googleapis.com/queue_size", "", opts)
+		return err
+	}
+
+	tests := []struct {
+		name        string
+		read        func(m googlecloud.GoogleCloudClient, opts googlecloud.QueryOptions) error
+		opts        googlecloud.QueryOptions
+		wantAligner monitoringpb.Aggregation_Aligner
+		wantReducer monitoringpb.Aggregation_Reducer
+	}{
+		{
+			name:        "aligns the latest value",
+			read:        readCPULoad,
+			opts:        googlecloud.QueryOptions{Aggregation: googlecloud.AggregationLatest},
+			wantAligner: monitoringpb.Aggregation_ALIGN_NEXT_OLDER,
+			wantReducer: monitoringpb.Aggregation_REDUCE_MEAN,
+		},
+		{
+			name:        "aligns the mean",
+			read:        readCPULoad,
+			opts:        googlecloud.QueryOptions{Aggregation: googlecloud.AggregationMean},
+			wantAligner: monitoringpb.Aggregation_ALIGN_MEAN,
+			wantReducer: monitoringpb.Aggregation_REDUCE_MEAN,
+		},
+		{
+			name:        "aligns the max",
+			read:        readCPULoad,
+			opts:        googlecloud.QueryOptions{Aggregation: googlecloud.AggregationMax},
+			wantAligner: monitoringpb.Aggregation_ALIGN_MAX,
+			wantReducer: monitoringpb.Aggregation_REDUCE_MAX,
+		},
+		{
+			name:        "aligns the 95th percentile",
+			read:        readCPULoad,
+			opts:        googlecloud.QueryOptions{Aggregation: googlecloud.AggregationP95},
+			wantAligner: monitoringpb.Aggregation_ALIGN_PERCENTILE_95,
+			wantReducer: monitoringpb.Aggregation_REDUCE_PERCENTILE_95,
+		},
+		{
+			name:        "sums the series of an arbitrary metric",
+			read:        readMetricValue,
+			opts:        googlecloud.QueryOptions{Aggregation: googlecloud.AggregationMean},
+			wantAligner: monitoringpb.Aggregation_ALIGN_MEAN,
+			wantReducer: monitoringpb.Aggregation_REDUCE_SUM,
+		},
+		{
+			name:        "aligns the rate",
+			read:        readMetricValue,
+			opts:        googlecloud.QueryOptions{Aggregation: googlecloud.AggregationMax, Rate: true},
+			wantAligner: monitoringpb.Aggregation_ALIGN_RATE,
+			wantReducer: monitoringpb.Aggregation_REDUCE_SUM,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMetricsClient := mocks.MetricClient{}
+			mockTimeSeriesIterator := mocks.TimeSeriesIterator{}
+			mockTimeSeriesIterator.On("Values").Return([]float64{0.5}, nil)
+			mockMetricsClient.On("ListTimeSeries", mock.Anything, mock.MatchedBy(func(req *monitoringpb.ListTimeSeriesRequest) bool {
+				return req.Aggregation.PerSeriesAligner == tt.wantAligner && req.Aggregation.CrossSeriesReducer == tt.wantReducer
+			})).Return(&mockTimeSeriesIterator)
+
+			m := googlecloud.NewClient(context.Background(), "my-project-id", "my-instance-id", "my-cluster-id", &mockMetricsClient, nil)
+			if err := tt.read(m, tt.opts); err != nil {
+				t.Errorf("googleCloudClient read error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_Credentials_Source(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials googlecloud.Credentials
+		want        googlecloud.CredentialsSource
+		wantOptions int
+	}{
+		{
+			name:        "uses the service account key",
+			credentials: googlecloud.Credentials{JSON: []byte(`{"type": "service_account"}`)},
+			want:        googlecloud.SecretCredentialsSource,
+			wantOptions: 1,
+		},
+		{
+			name:        "falls back to application default credentials",
+			credentials: googlecloud.Credentials{},
+			want:        googlecloud.ApplicationDefaultCredentialsSource,
+			wantOptions: 0,
+		},
+		{
+			name: "impersonates a service account",
+			credentials: googlecloud.Credentials{
+				ImpersonateServiceAccount: "autoscaler@cool-project.iam.gserviceaccount.com",
+				Delegates:                 []string{"delegate@cool-project.iam.gserviceaccount.com"},
+			},
+			want:        googlecloud.ApplicationDefaultCredentialsSource,
+			wantOptions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.credentials.Source(); got != tt.want {
+				t.Errorf("Credentials.Source() = %v, want %v", got, tt.want)
+			}
+			if got := len(tt.credentials.ClientOptions()); got != tt.wantOptions {
+				t.Errorf("len(Credentials.ClientOptions()) = %v, want %v", got, tt.wantOptions)
+			}
+		})
+	}
+}
+
+func Test_Credentials_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials googlecloud.Credentials
+		wantReason  googlecloud.CredentialsErrorReason
+	}{
+		{
+			name:        "accepts a service account key",
+			credentials: googlecloud.Credentials{JSON: []byte(`{"type": "service_account"}`)},
+		},
+		{
+			name:        "accepts application default credentials",
+			credentials: googlecloud.Credentials{},
+		},
+		{
+			name:        "rejects malformed JSON",
+			credentials: googlecloud.Credentials{JSON: []byte(`not json`)},
+			wantReason:  googlecloud.InvalidJSONReason,
+		},
+		{
+			name:        "rejects JSON without a type",
+			credentials: googlecloud.Credentials{JSON: []byte(`{"client_email": "me@cool-project.iam.gserviceaccount.com"}`)},
+			wantReason:  googlecloud.InvalidJSONReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.credentials.Validate()
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Errorf("Credentials.Validate() error = %v, want nil", err)
+				}
+
+				return
+			}
+
+			var credentialsErr *googlecloud.CredentialsError
+			if !errors.As(err, &credentialsErr) {
+				t.Fatalf("Credentials.Validate() error = %v, want a CredentialsError", err)
+			}
+			if credentialsErr.Reason != tt.wantReason {
+				t.Errorf("CredentialsError.Reason = %v, want %v", credentialsErr.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func Test_googleCloudClient_UpdateNodeCount(t *testing.T) {
+	bigtableClient := mocks.BigtableClient{}
+	bigtableClient.On("UpdateCluster", mock.Anything, "my-instance-id", "my-cluster-id", int32(5)).Return(nil)
+	bigtableClient.On("UpdateCluster", mock.Anything, "my-instance-id", "other-cluster-id", int32(5)).
+		Return(errors.New("cluster not found"))
+
+	m := googlecloud.NewClient(context.Background(), "my-project-id", "my-instance-id", "my-cluster-id", nil, &bigtableClient)
+
+	if err := m.UpdateNodeCount("my-cluster-id", 5); err != nil {
+		t.Errorf("googleCloudClient.UpdateNodeCount() error = %v", err)
+	}
+	if err := m.UpdateNodeCount("other-cluster-id", 5); err == nil {
+		t.Errorf("googleCloudClient.UpdateNodeCount() expected an error")
+	}
+}
@@ -7,20 +7,27 @@ import (
 )
 
 type GoogleCloudClient interface {
-	GetCurrentCPULoad() (int32, error)
+	GetCurrentCPULoad(opts QueryOptions) (int32, error)
+	GetCurrentHottestNodeCPULoad(opts QueryOptions) (int32, error)
+	GetCurrentStorageUtilization(opts QueryOptions) (int32, error)
+	GetCurrentMetricValue(metricType, filter string, opts QueryOptions) (float64, error)
 	GetCurrentNodeCount(clusterID string) (int32, error)
+	UpdateNodeCount(clusterID string, nodes int32) error
 }
 
 type MetricClient interface {
 	ListTimeSeries(ctx context.Context, req *monitoringpb.ListTimeSeriesRequest) TimeSeriesIterator
+	Close() error
 }
 
 type TimeSeriesIterator interface {
-	Points() ([]int32, error)
+	Values() ([]float64, error)
 }
 
 type BigtableClient interface {
 	Clusters(ctx context.Context, instanceID string) ([]ClusterInfo, error)
+	UpdateCluster(ctx context.Context, instanceID, clusterID string, serveNodes int32) error
+	Close() error
 }
 
 type ClusterInfo interface {
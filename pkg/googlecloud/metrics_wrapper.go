@@ -16,22 +16,28 @@ type timeSeriesIteratorWrapper struct {
 	iterator *monitoring.TimeSeriesIterator
 }
 
-func (w *timeSeriesIteratorWrapper) Points() ([]int32, error) {
-	const percent float64 = 100
+func (w *timeSeriesIteratorWrapper) Values() ([]float64, error) {
 	ts, err := w.iterator.Next()
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to iterate over time series: %w", err)
 	}
 
-	normalizedPoints := make([]int32, 0)
+	values := make([]float64, 0)
 
 	for _, point := range ts.Points {
-		value := point.GetValue().GetDoubleValue() * percent
-		normalizedPoints = append(normalizedPoints, int32(value))
+		values = append(values, pointValue(point.GetValue()))
 	}
 
-	return normalizedPoints, nil
+	return values, nil
+}
+
+func pointValue(value *monitoringpb.TypedValue) float64 {
+	if v, ok := value.GetValue().(*monitoringpb.TypedValue_Int64Value); ok {
+		return float64(v.Int64Value)
+	}
+
+	return value.GetDoubleValue()
 }
 
 func (w *metricClientWrapper) ListTimeSeries(
@@ -43,3 +49,7 @@ func (w *metricClientWrapper) ListTimeSeries(
 
 	return &ts
 }
+
+func (w *metricClientWrapper) Close() error {
+	return w.metricsClient.Close()
+}
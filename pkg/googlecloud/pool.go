@@ -0,0 +1,138 @@
+package googlecloud
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var poolSize = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "bigtable_autoscaler_google_cloud_client_pool_size",
+	Help: "Number of Google Cloud client sets kept open, one per credentials and project.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(poolSize)
+}
+
+// DialFunc dials the clients of a project, authenticated with the given credentials.
+type DialFunc func(ctx context.Context, credentials Credentials, projectID string) (MetricClient, BigtableClient, error)
+
+// ClientPool shares the clients of a project between the autoscalers using the same credentials,
+// so that they aren't dialed on every reconcile. The clients are closed once no autoscaler uses them.
+type ClientPool struct {
+	dial DialFunc
+
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+	owners  map[string]string
+}
+
+type poolEntry struct {
+	metricClient   MetricClient
+	bigtableClient BigtableClient
+	refs           int
+}
+
+func NewClientPool() *ClientPool {
+	return NewClientPoolWithDialer(dialClients)
+}
+
+// NewClientPoolWithDialer returns a pool whose clients are dialed by dial instead of dialClients.
+func NewClientPoolWithDialer(dial DialFunc) *ClientPool {
+	return &ClientPool{
+		dial:    dial,
+		entries: make(map[string]*poolEntry),
+		owners:  make(map[string]string),
+	}
+}
+
+// Get returns a client for the cluster, reusing the clients of the project when the owner, or
+// another one, already got them with the same credentials. Getting clients with other credentials
+// or for another project releases the ones the owner had.
+func (p *ClientPool) Get(ctx context.Context, owner string, credentials Credentials,
+	projectID, instanceID, clusterID string) (GoogleCloudClient, error) {
+	key := poolKey(credentials, projectID)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[key]
+	if !ok {
+		// The clients outlive the reconcile that dials them.
+		metricClient, bigtableClient, err := p.dial(context.Background(), credentials, projectID)
+		if err != nil {
+			return nil, err
+		}
+
+		entry = &poolEntry{metricClient: metricClient, bigtableClient: bigtableClient}
+		p.entries[key] = entry
+	}
+
+	if previous, ok := p.owners[owner]; !ok || previous != key {
+		p.release(owner)
+		entry.refs++
+		p.owners[owner] = key
+	}
+
+	poolSize.Set(float64(len(p.entries)))
+
+	return NewClient(ctx, projectID, instanceID, clusterID, entry.metricClient, entry.bigtableClient), nil
+}
+
+// Release releases the clients of the owner, closing them when no other owner uses them.
+func (p *ClientPool) Release(owner string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.release(owner)
+	poolSize.Set(float64(len(p.entries)))
+}
+
+// Size returns the number of client sets kept open.
+func (p *ClientPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.entries)
+}
+
+func (p *ClientPool) release(owner string) {
+	key, ok := p.owners[owner]
+	if !ok {
+		return
+	}
+
+	delete(p.owners, owner)
+
+	entry := p.entries[key]
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+
+	delete(p.entries, key)
+
+	// Close errors are ignored: the clients are dropped either way.
+	_ = entry.metricClient.Close()
+	_ = entry.bigtableClient.Close()
+}
+
+// poolKey identifies the clients of a project authenticated with the given credentials, without
+// keeping the service account key itself around.
+func poolKey(credentials Credentials, projectID string) string {
+	hash := sha256.New()
+	hash.Write(credentials.JSON)
+	hash.Write([]byte{0})
+	hash.Write([]byte(credentials.ImpersonateServiceAccount))
+	for _, delegate := range credentials.Delegates {
+		hash.Write([]byte{0})
+		hash.Write([]byte(delegate))
+	}
+
+	return projectID + "/" + hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package googlecloud_test
+
+import (
+	"context"
+	"testing"
+
+	"bigtable-autoscaler.com/m/v2/mocks"
+	"bigtable-autoscaler.com/m/v2/pkg/googlecloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientPool(t *testing.T) {
+	var dialed []*mocks.MetricClient
+	pool := googlecloud.NewClientPoolWithDialer(func(ctx context.Context, credentials googlecloud.Credentials,
+		projectID string) (googlecloud.MetricClient, googlecloud.BigtableClient, error) {
+		metricClient := &mocks.MetricClient{}
+		metricClient.On("Close").Return(nil)
+		bigtableClient := &mocks.BigtableClient{}
+		bigtableClient.On("Close").Return(nil)
+		dialed = append(dialed, metricClient)
+
+		return metricClient, bigtableClient, nil
+	})
+
+	ctx := context.Background()
+	key := googlecloud.Credentials{JSON: []byte(`{"type": "service_account"}`)}
+	otherKey := googlecloud.Credentials{JSON: []byte(`{"type": "service_account", "client_id": "other"}`)}
+
+	_, err := pool.Get(ctx, "default/first", key, "cool-project", "my-instance-id", "my-cluster-id")
+	assert.NoError(t, err)
+	_, err = pool.Get(ctx, "default/second", key, "cool-project", "my-instance-id", "other-cluster-id")
+	assert.NoError(t, err)
+	_, err = pool.Get(ctx, "default/first", key, "cool-project", "my-instance-id", "my-cluster-id")
+	assert.NoError(t, err)
+
+	assert.Len(t, dialed, 1, "autoscalers with the same credentials and project share the clients")
+	assert.Equal(t, 1, pool.Size())
+
+	_, err = pool.Get(ctx, "default/second", otherKey, "cool-project", "my-instance-id", "other-cluster-id")
+	assert.NoError(t, err)
+
+	assert.Len(t, dialed, 2)
+	assert.Equal(t, 2, pool.Size())
+	dialed[0].AssertNotCalled(t, "Close")
+
+	pool.Release("default/first")
+
+	assert.Equal(t, 1, pool.Size())
+	dialed[0].AssertCalled(t, "Close")
+
+	pool.Release("default/second")
+	pool.Release("default/second")
+
+	assert.Equal(t, 0, pool.Size())
+	dialed[1].AssertCalled(t, "Close")
+}
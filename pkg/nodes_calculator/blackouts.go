@@ -0,0 +1,45 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"time"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// ActiveScaleDownBlackout returns the first scale down blackout in effect at now, or nil when the
+// cluster can be scaled down.
+func ActiveScaleDownBlackout(spec *bigtablev2.BigtableAutoscalerSpec, now time.Time) *bigtablev2.ScaleDownBlackout {
+	for i := range spec.ScaleDownBlackouts {
+		blackout := &spec.ScaleDownBlackouts[i]
+		if blackoutActive(blackout, now) {
+			return blackout
+		}
+	}
+
+	return nil
+}
+
+func blackoutActive(blackout *bigtablev2.ScaleDownBlackout, now time.Time) bool {
+	if blackout.Cron != "" {
+		return blackout.Duration != nil && cronActive(blackout.Cron, blackout.TimeZone, blackout.Duration.Duration, now)
+	}
+
+	return blackout.StartTime != nil && blackout.EndTime != nil &&
+		!now.Before(blackout.StartTime.Time) && now.Before(blackout.EndTime.Time)
+}
@@ -0,0 +1,71 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+func TestActiveScaleDownBlackout(t *testing.T) {
+	freezeStart := metav1.NewTime(time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC))
+	freezeEnd := metav1.NewTime(time.Date(2021, 12, 27, 0, 0, 0, 0, time.UTC))
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		ScaleDownBlackouts: []bigtablev2.ScaleDownBlackout{
+			{
+				Name:      "release-freeze",
+				StartTime: &freezeStart,
+				EndTime:   &freezeEnd,
+			},
+			{
+				Name:     "month-end-closing",
+				Cron:     "0 18 28 * *",
+				TimeZone: "America/Sao_Paulo",
+				Duration: &metav1.Duration{Duration: 4 * 24 * time.Hour},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		now      time.Time
+		expected string
+	}{
+		"outside of any blackout":         {now: time.Date(2021, 12, 15, 12, 0, 0, 0, time.UTC)},
+		"within an absolute range":        {now: time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC), expected: "release-freeze"},
+		"at the end of an absolute range": {now: time.Date(2021, 12, 27, 0, 0, 0, 0, time.UTC)},
+		"within a recurring blackout":     {now: time.Date(2021, 11, 30, 22, 0, 0, 0, time.UTC), expected: "month-end-closing"},
+		"after a recurring blackout":      {now: time.Date(2021, 12, 3, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			blackout := ActiveScaleDownBlackout(spec, test.now)
+
+			got := ""
+			if blackout != nil {
+				got = blackout.Name
+			}
+			if got != test.expected {
+				t.Errorf("blackout = %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
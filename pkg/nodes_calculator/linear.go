@@ -0,0 +1,96 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"fmt"
+	"math"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// LinearStrategy scales the current number of nodes by the ratio between each metric and its
+// target. When several metrics are set, the highest number of nodes wins. Metrics without a
+// current value are ignored, and the current number of nodes is kept when none has one.
+type LinearStrategy struct{}
+
+// Recommend implements Strategy. Only the newest sample is used.
+func (LinearStrategy) Recommend(history []Sample, spec *bigtablev2.BigtableAutoscalerSpec) Recommendation {
+	sample := history[len(history)-1]
+
+	requiredNodes, driving := calcRequiredNodes(sample.Metrics, sample.Nodes, spec)
+	if driving < 0 {
+		return Recommendation{Nodes: requiredNodes, Explanation: "no metric has a current value"}
+	}
+
+	return Recommendation{
+		Nodes:       requiredNodes,
+		Explanation: fmt.Sprintf("the %s metric requires %d nodes", spec.Metrics[driving].Type, requiredNodes),
+	}
+}
+
+// calcRequiredNodes returns the required number of nodes and the index of the metric requiring
+// them, or the current number of nodes and -1 when no metric has a current value.
+func calcRequiredNodes(currentMetrics []bigtablev2.MetricStatus, currentNodes int32, spec *bigtablev2.BigtableAutoscalerSpec) (int32, int) {
+	requiredNodes := int32(0)
+	driving := -1
+
+	for i, metric := range spec.Metrics {
+		if i >= len(currentMetrics) || currentMetrics[i].Type != metric.Type {
+			continue
+		}
+
+		nodes, ok := calcMetricRequiredNodes(metric.Target, currentMetrics[i].Current, currentNodes)
+		if ok && (driving < 0 || nodes > requiredNodes) {
+			requiredNodes = nodes
+			driving = i
+		}
+	}
+
+	if driving < 0 {
+		return currentNodes, -1
+	}
+
+	return requiredNodes, driving
+}
+
+func calcMetricRequiredNodes(target bigtablev2.MetricTarget, current bigtablev2.MetricValueStatus, currentNodes int32) (int32, bool) {
+	switch target.Type {
+	case bigtablev2.UtilizationMetricType:
+		if target.AverageUtilization == nil || *target.AverageUtilization <= 0 || current.AverageUtilization == nil {
+			return 0, false
+		}
+
+		total := *current.AverageUtilization * currentNodes
+
+		return int32(math.Ceil(float64(total) / float64(*target.AverageUtilization))), true
+	case bigtablev2.ValueMetricType:
+		if target.Value == nil || target.Value.Sign() <= 0 || current.Value == nil {
+			return 0, false
+		}
+
+		return int32(math.Ceil(float64(currentNodes) * quantityToFloat(current.Value) / quantityToFloat(target.Value))), true
+	case bigtablev2.AverageValueMetricType:
+		if target.AverageValue == nil || target.AverageValue.Sign() <= 0 || current.Value == nil {
+			return 0, false
+		}
+
+		return int32(math.Ceil(quantityToFloat(current.Value) / quantityToFloat(target.AverageValue))), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,143 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+func TestLinearStrategy(t *testing.T) {
+	quantity := func(value string) *resource.Quantity {
+		q := resource.MustParse(value)
+		return &q
+	}
+	external := &bigtablev2.ExternalMetricSource{MetricType: "custom.googleapis.com/queue_size"}
+
+	tests := map[string]struct {
+		metrics        []bigtablev2.MetricSpec
+		currentMetrics []bigtablev2.MetricStatus
+		expected       int32
+		explanation    string
+	}{
+		"cpu only": {
+			metrics:        []bigtablev2.MetricSpec{cpuMetric(50)},
+			currentMetrics: []bigtablev2.MetricStatus{cpuStatus(80)},
+			expected:       7,
+			explanation:    "the CPU metric requires 7 nodes",
+		},
+		"highest metric wins": {
+			metrics: []bigtablev2.MetricSpec{
+				cpuMetric(50),
+				{
+					Type:   bigtablev2.StorageMetricSourceType,
+					Target: bigtablev2.MetricTarget{Type: bigtablev2.UtilizationMetricType, AverageUtilization: pointer.Int32(60)},
+				},
+			},
+			currentMetrics: []bigtablev2.MetricStatus{
+				cpuStatus(20),
+				{
+					Type:    bigtablev2.StorageMetricSourceType,
+					Current: bigtablev2.MetricValueStatus{AverageUtilization: pointer.Int32(90)},
+				},
+			},
+			expected:    6,
+			explanation: "the Storage metric requires 6 nodes",
+		},
+		"hottest node cpu wins over average cpu": {
+			metrics: []bigtablev2.MetricSpec{
+				cpuMetric(50),
+				{
+					Type:   bigtablev2.HottestNodeCPUMetricSourceType,
+					Target: bigtablev2.MetricTarget{Type: bigtablev2.UtilizationMetricType, AverageUtilization: pointer.Int32(70)},
+				},
+			},
+			currentMetrics: []bigtablev2.MetricStatus{
+				cpuStatus(40),
+				{
+					Type:    bigtablev2.HottestNodeCPUMetricSourceType,
+					Current: bigtablev2.MetricValueStatus{AverageUtilization: pointer.Int32(95)},
+				},
+			},
+			expected:    6,
+			explanation: "the HottestNodeCPU metric requires 6 nodes",
+		},
+		"external value": {
+			metrics: []bigtablev2.MetricSpec{
+				{
+					Type:     bigtablev2.ExternalMetricSourceType,
+					External: external,
+					Target:   bigtablev2.MetricTarget{Type: bigtablev2.ValueMetricType, Value: quantity("100")},
+				},
+			},
+			currentMetrics: []bigtablev2.MetricStatus{
+				{
+					Type:     bigtablev2.ExternalMetricSourceType,
+					External: external,
+					Current:  bigtablev2.MetricValueStatus{Value: quantity("250")},
+				},
+			},
+			expected:    10,
+			explanation: "the External metric requires 10 nodes",
+		},
+		"external average value": {
+			metrics: []bigtablev2.MetricSpec{
+				{
+					Type:     bigtablev2.ExternalMetricSourceType,
+					External: external,
+					Target:   bigtablev2.MetricTarget{Type: bigtablev2.AverageValueMetricType, AverageValue: quantity("1k")},
+				},
+			},
+			currentMetrics: []bigtablev2.MetricStatus{
+				{
+					Type:     bigtablev2.ExternalMetricSourceType,
+					External: external,
+					Current:  bigtablev2.MetricValueStatus{Value: quantity("7500")},
+				},
+			},
+			expected:    8,
+			explanation: "the External metric requires 8 nodes",
+		},
+		"metrics not read yet": {
+			metrics:     []bigtablev2.MetricSpec{cpuMetric(50)},
+			expected:    4,
+			explanation: "no metric has a current value",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sample := Sample{Nodes: 4, Metrics: test.currentMetrics}
+			spec := &bigtablev2.BigtableAutoscalerSpec{Metrics: test.metrics}
+
+			recommendation := LinearStrategy{}.Recommend([]Sample{sample}, spec)
+
+			if recommendation.Nodes != test.expected {
+				t.Errorf("expected: %v, got: %v", test.expected, recommendation.Nodes)
+			}
+
+			if recommendation.Explanation != test.explanation {
+				t.Errorf("expected explanation: %q, got: %q", test.explanation, recommendation.Explanation)
+			}
+		})
+	}
+}
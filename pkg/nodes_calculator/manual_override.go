@@ -0,0 +1,80 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// DetectManualOverride compares the observed number of nodes with the last one the autoscaler
+// applied, and records a difference as a manual override detected at now. It reports whether a
+// new override was detected, which is not the case when the nodes still are those of the last one.
+func DetectManualOverride(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec,
+	currentNodes int32, now time.Time) bool {
+	if status.LastAppliedNodes == nil || manualOverrideGracePeriod(spec) == 0 {
+		status.LastAppliedNodes = &currentNodes
+		status.ManualOverride = nil
+
+		return false
+	}
+
+	if currentNodes == *status.LastAppliedNodes {
+		status.ManualOverride = nil
+
+		return false
+	}
+
+	if status.ManualOverride != nil && status.ManualOverride.Nodes == currentNodes {
+		return false
+	}
+
+	status.ManualOverride = &bigtablev2.ManualOverride{
+		Nodes:        currentNodes,
+		DetectedTime: metav1.NewTime(now),
+	}
+
+	return true
+}
+
+// ManualOverrideUntil returns when the autoscaler takes the cluster over again after the last
+// manual override, or false when there is none.
+func ManualOverrideUntil(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec) (time.Time, bool) {
+	if status.ManualOverride == nil {
+		return time.Time{}, false
+	}
+
+	return status.ManualOverride.DetectedTime.Add(manualOverrideGracePeriod(spec)), true
+}
+
+// AdoptNodes makes nodes the baseline manual overrides are detected against, once the autoscaler
+// applied them or took the cluster over again after a manual override.
+func AdoptNodes(status *bigtablev2.BigtableAutoscalerStatus, nodes int32) {
+	status.LastAppliedNodes = &nodes
+	status.ManualOverride = nil
+}
+
+func manualOverrideGracePeriod(spec *bigtablev2.BigtableAutoscalerSpec) time.Duration {
+	if spec.ManualOverrideGracePeriod == nil || spec.ManualOverrideGracePeriod.Duration < 0 {
+		return 0
+	}
+
+	return spec.ManualOverrideGracePeriod.Duration
+}
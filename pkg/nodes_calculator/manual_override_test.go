@@ -0,0 +1,128 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+func TestDetectManualOverride(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	earlier := metav1.NewTime(now.Add(-time.Minute))
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		ManualOverrideGracePeriod: &metav1.Duration{Duration: 10 * time.Minute},
+	}
+
+	tests := map[string]struct {
+		status           bigtablev2.BigtableAutoscalerStatus
+		spec             *bigtablev2.BigtableAutoscalerSpec
+		currentNodes     int32
+		expectedDetected bool
+		expectedOverride *bigtablev2.ManualOverride
+		expectedApplied  int32
+	}{
+		"starts from the observed nodes": {
+			status:          bigtablev2.BigtableAutoscalerStatus{},
+			spec:            spec,
+			currentNodes:    4,
+			expectedApplied: 4,
+		},
+		"nodes unchanged": {
+			status:          bigtablev2.BigtableAutoscalerStatus{LastAppliedNodes: pointer.Int32(4)},
+			spec:            spec,
+			currentNodes:    4,
+			expectedApplied: 4,
+		},
+		"nodes changed by someone else": {
+			status:           bigtablev2.BigtableAutoscalerStatus{LastAppliedNodes: pointer.Int32(4)},
+			spec:             spec,
+			currentNodes:     8,
+			expectedDetected: true,
+			expectedOverride: &bigtablev2.ManualOverride{Nodes: 8, DetectedTime: metav1.NewTime(now)},
+			expectedApplied:  4,
+		},
+		"same override as before": {
+			status: bigtablev2.BigtableAutoscalerStatus{
+				LastAppliedNodes: pointer.Int32(4),
+				ManualOverride:   &bigtablev2.ManualOverride{Nodes: 8, DetectedTime: earlier},
+			},
+			spec:             spec,
+			currentNodes:     8,
+			expectedOverride: &bigtablev2.ManualOverride{Nodes: 8, DetectedTime: earlier},
+			expectedApplied:  4,
+		},
+		"override reverted": {
+			status: bigtablev2.BigtableAutoscalerStatus{
+				LastAppliedNodes: pointer.Int32(4),
+				ManualOverride:   &bigtablev2.ManualOverride{Nodes: 8, DetectedTime: earlier},
+			},
+			spec:            spec,
+			currentNodes:    4,
+			expectedApplied: 4,
+		},
+		"detection disabled": {
+			status:          bigtablev2.BigtableAutoscalerStatus{LastAppliedNodes: pointer.Int32(4)},
+			spec:            &bigtablev2.BigtableAutoscalerSpec{ManualOverrideGracePeriod: &metav1.Duration{}},
+			currentNodes:    8,
+			expectedApplied: 8,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := test.status
+
+			detected := DetectManualOverride(&status, test.spec, test.currentNodes, now)
+
+			if detected != test.expectedDetected {
+				t.Errorf("detected = %v, want %v", detected, test.expectedDetected)
+			}
+			if (status.ManualOverride == nil) != (test.expectedOverride == nil) ||
+				status.ManualOverride != nil && (status.ManualOverride.Nodes != test.expectedOverride.Nodes ||
+					!status.ManualOverride.DetectedTime.Equal(&test.expectedOverride.DetectedTime)) {
+				t.Errorf("manual override = %v, want %v", status.ManualOverride, test.expectedOverride)
+			}
+			if status.LastAppliedNodes == nil || *status.LastAppliedNodes != test.expectedApplied {
+				t.Errorf("last applied nodes = %v, want %d", status.LastAppliedNodes, test.expectedApplied)
+			}
+		})
+	}
+}
+
+func TestManualOverrideUntil(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		ManualOverrideGracePeriod: &metav1.Duration{Duration: 10 * time.Minute},
+	}
+
+	if _, ok := ManualOverrideUntil(&bigtablev2.BigtableAutoscalerStatus{}, spec); ok {
+		t.Errorf("expected no manual override")
+	}
+
+	status := &bigtablev2.BigtableAutoscalerStatus{
+		ManualOverride: &bigtablev2.ManualOverride{Nodes: 8, DetectedTime: metav1.NewTime(now)},
+	}
+	if until, ok := ManualOverrideUntil(status, spec); !ok || !until.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("ManualOverrideUntil() = %v, %v, want %v", until, ok, now.Add(10*time.Minute))
+	}
+}
@@ -18,28 +18,89 @@ package nodes_calculator
 
 import (
 	"math"
+	"time"
 
-	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
 )
 
-func CalcDesiredNodes(status *bigtablev1.BigtableAutoscalerStatus, spec *bigtablev1.BigtableAutoscalerSpec) int32 {
+// CalcDesiredNodes returns the number of nodes to scale to: the required number of nodes,
+// stabilized by the recommendations within the stabilization windows, with the scaling
+// policies, the scale down step and the min/max limits applied.
+func CalcDesiredNodes(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec, now time.Time) int32 {
 	currentNodes := *status.CurrentNodes
-	totalCPU := *status.CurrentCPUUtilization * currentNodes
-	desiredNodes := int32(math.Ceil(float64(totalCPU) / float64(*spec.TargetCPUUtilization)))
+	scaleUpNodes, scaleDownNodes := CalcStabilizedRecommendations(status, spec, now)
+
+	desiredNodes := currentNodes
+	if desiredNodes < scaleUpNodes {
+		desiredNodes = scaleUpNodes
+	}
+	if desiredNodes > scaleDownNodes {
+		desiredNodes = scaleDownNodes
+	}
+
+	desiredNodes = applyScalingPolicies(status, spec, currentNodes, desiredNodes, now)
 
 	if (currentNodes - desiredNodes) > *spec.MaxScaleDownNodes {
 		desiredNodes = currentNodes - *spec.MaxScaleDownNodes
 	}
 
-	return ensureLimits(desiredNodes, *spec.MinNodes, *spec.MaxNodes)
+	return ensureLimits(desiredNodes, CalcEffectiveMinNodes(status, spec), *spec.MaxNodes)
 }
 
+// CalcEffectiveMinNodes returns the lowest number of nodes the cluster can be scaled to:
+// the highest of MinNodes and the number of nodes needed to hold the stored data.
+func CalcEffectiveMinNodes(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec) int32 {
+	minNodes := *spec.MinNodes
+
+	if storageNodes := CalcStorageMinNodes(status); storageNodes > minNodes {
+		return storageNodes
+	}
+
+	return minNodes
+}
+
+// CalcStorageMinNodes returns the number of nodes needed to keep the storage utilization
+// within the storage limit of the nodes. Bigtable refuses to scale below it.
+func CalcStorageMinNodes(status *bigtablev2.BigtableAutoscalerStatus) int32 {
+	const maxStorageUtilization = 100
+
+	if status.CurrentStorageUtilization == nil || status.CurrentNodes == nil {
+		return 0
+	}
+
+	totalStorage := *status.CurrentStorageUtilization * *status.CurrentNodes
+
+	return int32(math.Ceil(float64(totalStorage) / maxStorageUtilization))
+}
+
+// CalcRequiredNodes returns the number of nodes the strategy selected by the spec recommends,
+// before the stabilization windows, the scaling policies and the limits are applied. The current
+// number of nodes is kept when the strategy isn't registered.
+func CalcRequiredNodes(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec) int32 {
+	recommendation, err := Recommend(status, spec)
+	if err != nil {
+		return *status.CurrentNodes
+	}
+
+	return recommendation.Nodes
+}
+
+func quantityToFloat(q *resource.Quantity) float64 {
+	return float64(q.MilliValue()) / 1000
+}
+
+// ensureLimits clamps n between min and max. min wins when it is above max, since the
+// storage floor can't be ignored.
 func ensureLimits(n int32, min int32, max int32) int32 {
+	if n > max {
+		n = max
+	}
+
 	if n < min {
 		return min
-	} else if n > max {
-		return max
-	} else {
-		return n
 	}
+
+	return n
 }
@@ -18,15 +18,16 @@ package nodes_calculator
 
 import (
 	"testing"
+	"time"
 
 	"bigtable-autoscaler.com/m/v2/pkg/pointer"
 
-	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
 )
 
 func TestCalcDesiredNodes(t *testing.T) {
-	var status *bigtablev1.BigtableAutoscalerStatus
-	var spec *bigtablev1.BigtableAutoscalerSpec
+	var status *bigtablev2.BigtableAutoscalerStatus
+	var spec *bigtablev2.BigtableAutoscalerSpec
 
 	tests := map[string]struct {
 		currentNodes int32
@@ -46,19 +47,19 @@ func TestCalcDesiredNodes(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			status = &bigtablev1.BigtableAutoscalerStatus{
-				CurrentNodes:          pointer.Int32(test.currentNodes),
-				CurrentCPUUtilization: pointer.Int32(test.currentCPU),
+			status = &bigtablev2.BigtableAutoscalerStatus{
+				CurrentNodes:   pointer.Int32(test.currentNodes),
+				CurrentMetrics: []bigtablev2.MetricStatus{cpuStatus(test.currentCPU)},
 			}
 
-			spec = &bigtablev1.BigtableAutoscalerSpec{
-				MinNodes:             pointer.Int32(test.minNodes),
-				MaxNodes:             pointer.Int32(test.maxNodes),
-				TargetCPUUtilization: pointer.Int32(test.targetCPU),
-				MaxScaleDownNodes:    pointer.Int32(test.maxScaleDown),
+			spec = &bigtablev2.BigtableAutoscalerSpec{
+				MinNodes:          pointer.Int32(test.minNodes),
+				MaxNodes:          pointer.Int32(test.maxNodes),
+				Metrics:           []bigtablev2.MetricSpec{cpuMetric(test.targetCPU)},
+				MaxScaleDownNodes: pointer.Int32(test.maxScaleDown),
 			}
 
-			nodes := CalcDesiredNodes(status, spec)
+			nodes := CalcDesiredNodes(status, spec, time.Now())
 
 			if nodes != test.expected {
 				t.Errorf("expected: %v, got: %v", test.expected, nodes)
@@ -66,3 +67,55 @@ func TestCalcDesiredNodes(t *testing.T) {
 		})
 	}
 }
+
+func TestCalcDesiredNodesStorageFloor(t *testing.T) {
+	tests := map[string]struct {
+		storageUtilization *int32
+		maxNodes           int32
+		expected           int32
+	}{
+		"storage not read yet":  {storageUtilization: nil, maxNodes: 10, expected: 1},
+		"floor below min nodes": {storageUtilization: pointer.Int32(5), maxNodes: 10, expected: 1},
+		"floor above min nodes": {storageUtilization: pointer.Int32(70), maxNodes: 10, expected: 6},
+		"floor above max nodes": {storageUtilization: pointer.Int32(95), maxNodes: 4, expected: 8},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := &bigtablev2.BigtableAutoscalerStatus{
+				CurrentNodes:              pointer.Int32(8),
+				CurrentMetrics:            []bigtablev2.MetricStatus{cpuStatus(5)},
+				CurrentStorageUtilization: test.storageUtilization,
+			}
+			spec := &bigtablev2.BigtableAutoscalerSpec{
+				MinNodes:          pointer.Int32(1),
+				MaxNodes:          pointer.Int32(test.maxNodes),
+				MaxScaleDownNodes: pointer.Int32(10),
+				Metrics:           []bigtablev2.MetricSpec{cpuMetric(50)},
+			}
+
+			nodes := CalcDesiredNodes(status, spec, time.Now())
+
+			if nodes != test.expected {
+				t.Errorf("expected: %v, got: %v", test.expected, nodes)
+			}
+		})
+	}
+}
+
+func cpuMetric(target int32) bigtablev2.MetricSpec {
+	return bigtablev2.MetricSpec{
+		Type: bigtablev2.CPUMetricSourceType,
+		Target: bigtablev2.MetricTarget{
+			Type:               bigtablev2.UtilizationMetricType,
+			AverageUtilization: pointer.Int32(target),
+		},
+	}
+}
+
+func cpuStatus(current int32) bigtablev2.MetricStatus {
+	return bigtablev2.MetricStatus{
+		Type:    bigtablev2.CPUMetricSourceType,
+		Current: bigtablev2.MetricValueStatus{AverageUtilization: pointer.Int32(current)},
+	}
+}
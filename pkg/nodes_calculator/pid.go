@@ -0,0 +1,199 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"fmt"
+	"math"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// PIDStrategy scales the current number of nodes by the output of a PID controller on the
+// relative error of the metric requiring the most nodes: 0 on target, 0.5 when it is 50% above.
+// The proportional term alone, with a gain of 1, recommends what LinearStrategy does. The
+// integral and derivative terms are kept in status.pid by Advance, once per sample.
+type PIDStrategy struct{}
+
+// Recommend implements Strategy. Only the newest sample is used: the previous ones are folded
+// into the integral and derivative kept in the status.
+func (PIDStrategy) Recommend(history []Sample, spec *bigtablev2.BigtableAutoscalerSpec) Recommendation {
+	sample := history[len(history)-1]
+
+	errorValue, driving := calcPIDError(sample.Metrics, sample.Nodes, spec)
+	if driving < 0 {
+		return Recommendation{Nodes: sample.Nodes, Explanation: "no metric has a current value"}
+	}
+
+	var integral, derivative float64
+	if sample.PID != nil {
+		integral = quantityToFloat(&sample.PID.Integral)
+		derivative = quantityToFloat(&sample.PID.Derivative)
+	}
+
+	gains := pidGainsOf(spec)
+	output := gains.proportional*errorValue + gains.integral*integral + gains.derivative*derivative
+
+	// The epsilon keeps rounding errors from adding a node when the output is a whole number.
+	nodes := int32(math.Ceil(float64(sample.Nodes)*(1+output) - 1e-9))
+	if nodes < 0 {
+		nodes = 0
+	}
+
+	return Recommendation{
+		Nodes: nodes,
+		Explanation: fmt.Sprintf("the PID controller on the %s metric requires %d nodes (error %.2f, integral %.2f, derivative %.2f)",
+			spec.Metrics[driving].Type, nodes, errorValue, integral, derivative),
+	}
+}
+
+// Advance implements StatefulStrategy. The integral is clamped so that its term never adds or
+// removes more than integralLimit of the nodes, and the derivative is smoothed exponentially.
+func (PIDStrategy) Advance(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec) {
+	sample := CurrentSample(status)
+	if sample.Time.IsZero() {
+		return
+	}
+
+	errorValue, driving := calcPIDError(sample.Metrics, sample.Nodes, spec)
+	if driving < 0 {
+		return
+	}
+
+	state := status.PID
+	if state != nil && !sample.Time.After(state.LastSampleTime.Time) {
+		return
+	}
+
+	gains := pidGainsOf(spec)
+
+	var integral, derivative float64
+	if state != nil {
+		minutes := sample.Time.Sub(state.LastSampleTime.Time).Minutes()
+		integral = quantityToFloat(&state.Integral) + errorValue*minutes
+
+		change := (errorValue - quantityToFloat(&state.LastError)) / minutes
+		derivative = gains.smoothing*quantityToFloat(&state.Derivative) + (1-gains.smoothing)*change
+	}
+
+	if gains.integral > 0 {
+		bound := gains.integralLimit / gains.integral
+		integral = math.Max(-bound, math.Min(bound, integral))
+	} else {
+		integral = 0
+	}
+
+	status.PID = &bigtablev2.PIDStatus{
+		Integral:       floatToQuantity(integral),
+		Derivative:     floatToQuantity(derivative),
+		LastError:      floatToQuantity(errorValue),
+		LastSampleTime: metav1.Time{Time: sample.Time},
+	}
+}
+
+type pidGains struct {
+	proportional  float64
+	integral      float64
+	derivative    float64
+	integralLimit float64
+	smoothing     float64
+}
+
+// pidGainsOf returns the gains of the spec, with the defaults of the webhook for the ones not set.
+func pidGainsOf(spec *bigtablev2.BigtableAutoscalerSpec) pidGains {
+	pid := spec.PID
+	if pid == nil {
+		pid = &bigtablev2.PIDStrategy{}
+	}
+
+	gain := func(q *resource.Quantity, defaultValue string) float64 {
+		if q == nil {
+			value := resource.MustParse(defaultValue)
+			q = &value
+		}
+
+		return quantityToFloat(q)
+	}
+
+	smoothing := bigtablev2.DefaultPIDDerivativeSmoothing
+	if pid.DerivativeSmoothing != nil {
+		smoothing = *pid.DerivativeSmoothing
+	}
+
+	return pidGains{
+		proportional:  gain(pid.ProportionalGain, bigtablev2.DefaultPIDProportionalGain),
+		integral:      gain(pid.IntegralGain, bigtablev2.DefaultPIDIntegralGain),
+		derivative:    gain(pid.DerivativeGain, bigtablev2.DefaultPIDDerivativeGain),
+		integralLimit: gain(pid.IntegralLimit, bigtablev2.DefaultPIDIntegralLimit),
+		smoothing:     float64(smoothing) / 100,
+	}
+}
+
+// calcPIDError returns the relative error of the metric farthest above its target, or below it
+// when none is above, and its index, or -1 when no metric has a current value.
+func calcPIDError(currentMetrics []bigtablev2.MetricStatus, currentNodes int32, spec *bigtablev2.BigtableAutoscalerSpec) (float64, int) {
+	errorValue := 0.0
+	driving := -1
+
+	for i, metric := range spec.Metrics {
+		if i >= len(currentMetrics) || currentMetrics[i].Type != metric.Type {
+			continue
+		}
+
+		ratio, ok := calcMetricRatio(metric.Target, currentMetrics[i].Current, currentNodes)
+		if ok && (driving < 0 || ratio-1 > errorValue) {
+			errorValue = ratio - 1
+			driving = i
+		}
+	}
+
+	return errorValue, driving
+}
+
+// calcMetricRatio returns the ratio between the current value of the metric and its target, by
+// which the linear strategy scales the current number of nodes.
+func calcMetricRatio(target bigtablev2.MetricTarget, current bigtablev2.MetricValueStatus, currentNodes int32) (float64, bool) {
+	switch target.Type {
+	case bigtablev2.UtilizationMetricType:
+		if target.AverageUtilization == nil || *target.AverageUtilization <= 0 || current.AverageUtilization == nil {
+			return 0, false
+		}
+
+		return float64(*current.AverageUtilization) / float64(*target.AverageUtilization), true
+	case bigtablev2.ValueMetricType:
+		if target.Value == nil || target.Value.Sign() <= 0 || current.Value == nil {
+			return 0, false
+		}
+
+		return quantityToFloat(current.Value) / quantityToFloat(target.Value), true
+	case bigtablev2.AverageValueMetricType:
+		if target.AverageValue == nil || target.AverageValue.Sign() <= 0 || current.Value == nil || currentNodes <= 0 {
+			return 0, false
+		}
+
+		return quantityToFloat(current.Value) / (quantityToFloat(target.AverageValue) * float64(currentNodes)), true
+	default:
+		return 0, false
+	}
+}
+
+func floatToQuantity(f float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(math.Round(f*1000)), resource.DecimalSI)
+}
@@ -0,0 +1,216 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+func pidSpec(targetCPU int32, pid *bigtablev2.PIDStrategy) *bigtablev2.BigtableAutoscalerSpec {
+	return &bigtablev2.BigtableAutoscalerSpec{
+		MinNodes:          pointer.Int32(1),
+		MaxNodes:          pointer.Int32(10),
+		MaxScaleDownNodes: pointer.Int32(10),
+		Metrics:           []bigtablev2.MetricSpec{cpuMetric(targetCPU)},
+		Strategy:          bigtablev2.PIDScalingStrategy,
+		PID:               pid,
+	}
+}
+
+func gains(proportional, integral, derivative string) *bigtablev2.PIDStrategy {
+	quantity := func(value string) *resource.Quantity {
+		q := resource.MustParse(value)
+		return &q
+	}
+
+	return &bigtablev2.PIDStrategy{
+		ProportionalGain: quantity(proportional),
+		IntegralGain:     quantity(integral),
+		DerivativeGain:   quantity(derivative),
+	}
+}
+
+func pidState(integral, derivative, lastError string, lastSampleTime time.Time) *bigtablev2.PIDStatus {
+	return &bigtablev2.PIDStatus{
+		Integral:       resource.MustParse(integral),
+		Derivative:     resource.MustParse(derivative),
+		LastError:      resource.MustParse(lastError),
+		LastSampleTime: metav1.Time{Time: lastSampleTime},
+	}
+}
+
+func TestPIDStrategy(t *testing.T) {
+	tests := map[string]struct {
+		currentCPU int32
+		pid        *bigtablev2.PIDStrategy
+		state      *bigtablev2.PIDStatus
+		expected   int32
+	}{
+		"proportional only matches linear": {
+			currentCPU: 80,
+			pid:        gains("1", "0", "0"),
+			expected:   7,
+		},
+		"proportional only on target": {
+			currentCPU: 50,
+			pid:        gains("1", "0", "0"),
+			expected:   4,
+		},
+		"damped proportional": {
+			currentCPU: 80,
+			pid:        gains("0.5", "0", "0"),
+			expected:   6,
+		},
+		"integral adds nodes": {
+			currentCPU: 80,
+			pid:        gains("1", "0.1", "0"),
+			state:      pidState("2", "0", "0.6", time.Time{}),
+			expected:   8,
+		},
+		"derivative anticipates a drop": {
+			currentCPU: 50,
+			pid:        gains("1", "0", "1"),
+			state:      pidState("0", "-0.5", "0", time.Time{}),
+			expected:   2,
+		},
+		"scale down": {
+			currentCPU: 20,
+			pid:        gains("1", "0", "0"),
+			expected:   2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sample := Sample{Nodes: 4, Metrics: []bigtablev2.MetricStatus{cpuStatus(test.currentCPU)}, PID: test.state}
+
+			recommendation := PIDStrategy{}.Recommend([]Sample{sample}, pidSpec(50, test.pid))
+
+			if recommendation.Nodes != test.expected {
+				t.Errorf("expected: %v, got: %v (%s)", test.expected, recommendation.Nodes, recommendation.Explanation)
+			}
+		})
+	}
+}
+
+func TestPIDStrategyWithoutMetrics(t *testing.T) {
+	sample := Sample{Nodes: 4}
+
+	recommendation := PIDStrategy{}.Recommend([]Sample{sample}, pidSpec(50, nil))
+
+	if recommendation.Nodes != 4 || recommendation.Explanation != "no metric has a current value" {
+		t.Errorf("expected the current nodes without metrics, got: %v (%s)", recommendation.Nodes, recommendation.Explanation)
+	}
+}
+
+func TestPIDStrategyAdvance(t *testing.T) {
+	start := time.Date(2021, 11, 26, 12, 0, 0, 0, time.UTC)
+	spec := pidSpec(50, gains("1", "0.1", "1"))
+	spec.PID.IntegralLimit = resource.NewMilliQuantity(500, resource.DecimalSI)
+	spec.PID.DerivativeSmoothing = pointer.Int32(50)
+
+	status := &bigtablev2.BigtableAutoscalerStatus{CurrentNodes: pointer.Int32(4)}
+	sample := func(cpu int32, at time.Time) {
+		status.CurrentMetrics = []bigtablev2.MetricStatus{cpuStatus(cpu)}
+		status.LastFetchTime = &metav1.Time{Time: at}
+		PIDStrategy{}.Advance(status, spec)
+	}
+	assertState := func(integral, derivative, lastError int64) {
+		t.Helper()
+
+		if status.PID == nil {
+			t.Fatalf("expected a PID state")
+		}
+
+		got := []int64{status.PID.Integral.MilliValue(), status.PID.Derivative.MilliValue(), status.PID.LastError.MilliValue()}
+		expected := []int64{integral, derivative, lastError}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("expected integral, derivative and last error (in thousandths): %v, got: %v", expected, got)
+				break
+			}
+		}
+	}
+
+	sample(80, start)
+	assertState(0, 0, 600)
+
+	// The same sample is only folded in once.
+	sample(80, start)
+	assertState(0, 0, 600)
+
+	sample(80, start.Add(time.Minute))
+	assertState(600, 0, 600)
+
+	sample(100, start.Add(2*time.Minute))
+	assertState(1600, 200, 1000)
+
+	// The integral term is clamped at half of the nodes: 0.5 / 0.1.
+	sample(100, start.Add(12*time.Minute))
+	assertState(5000, 100, 1000)
+
+	// Recommend reads the state back from the status: 4 * (1 + 1 + 0.1 * 5 + 1 * 0.1).
+	recommendation := PIDStrategy{}.Recommend(History(status), spec)
+	if recommendation.Nodes != 11 {
+		t.Errorf("expected: 11, got: %v (%s)", recommendation.Nodes, recommendation.Explanation)
+	}
+}
+
+func TestPIDStrategyRespectsLimits(t *testing.T) {
+	now := time.Date(2021, 11, 26, 12, 0, 0, 0, time.UTC)
+	spec := pidSpec(50, gains("1", "0.1", "0"))
+	spec.MaxNodes = pointer.Int32(6)
+
+	status := &bigtablev2.BigtableAutoscalerStatus{
+		CurrentNodes:   pointer.Int32(4),
+		CurrentMetrics: []bigtablev2.MetricStatus{cpuStatus(80)},
+		PID:            pidState("5", "0", "0.6", now),
+	}
+
+	if nodes := CalcDesiredNodes(status, spec, now); nodes != 6 {
+		t.Errorf("expected: 6, got: %v", nodes)
+	}
+}
+
+func TestAdvanceStrategy(t *testing.T) {
+	now := time.Date(2021, 11, 26, 12, 0, 0, 0, time.UTC)
+	status := &bigtablev2.BigtableAutoscalerStatus{
+		CurrentNodes:   pointer.Int32(4),
+		CurrentMetrics: []bigtablev2.MetricStatus{cpuStatus(80)},
+		LastFetchTime:  &metav1.Time{Time: now},
+	}
+
+	AdvanceStrategy(status, pidSpec(50, nil))
+	if status.PID == nil {
+		t.Fatalf("expected the PID strategy to keep its state")
+	}
+
+	spec := pidSpec(50, nil)
+	spec.Strategy = bigtablev2.LinearScalingStrategy
+	AdvanceStrategy(status, spec)
+	if status.PID != nil {
+		t.Errorf("expected the state of the PID strategy to be dropped, got: %v", status.PID)
+	}
+}
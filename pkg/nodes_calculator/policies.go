@@ -0,0 +1,197 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"math"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// AddScaleEvent records a change of nodes made at now, and drops the events that are
+// older than the longest policy period.
+func AddScaleEvent(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec, change int32, now time.Time) {
+	events := append(status.ScaleEvents, bigtablev2.ScaleEvent{
+		Time:   metav1.NewTime(now),
+		Change: change,
+	})
+
+	periodStart := now.Add(-longestPolicyPeriod(spec))
+
+	first := 0
+	for first < len(events) && !events[first].Time.Time.After(periodStart) {
+		first++
+	}
+
+	status.ScaleEvents = append([]bigtablev2.ScaleEvent(nil), events[first:]...)
+}
+
+// applyScalingPolicies limits the change from currentNodes to desiredNodes according to
+// the policies of the scaling direction.
+func applyScalingPolicies(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec,
+	currentNodes, desiredNodes int32, now time.Time) int32 {
+	var scaleUp, scaleDown *bigtablev2.ScalingRules
+	if spec.Behavior != nil {
+		scaleUp = spec.Behavior.ScaleUp
+		scaleDown = spec.Behavior.ScaleDown
+	}
+
+	if desiredNodes > currentNodes {
+		if limit, ok := scaleUpLimit(scaleUp, status.ScaleEvents, currentNodes, now); ok && desiredNodes > limit {
+			return limit
+		}
+	}
+
+	if desiredNodes < currentNodes {
+		if limit, ok := scaleDownLimit(scaleDown, status.ScaleEvents, currentNodes, now); ok && desiredNodes < limit {
+			return limit
+		}
+	}
+
+	return desiredNodes
+}
+
+func scaleUpLimit(rules *bigtablev2.ScalingRules, events []bigtablev2.ScaleEvent, currentNodes int32, now time.Time) (int32, bool) {
+	if rules == nil {
+		return 0, false
+	}
+
+	selectPolicy := selectedPolicy(rules)
+	if selectPolicy == bigtablev2.DisabledPolicySelect {
+		return currentNodes, true
+	}
+
+	if len(rules.Policies) == 0 {
+		return 0, false
+	}
+
+	var limit int32
+	for i, policy := range rules.Policies {
+		periodStartNodes := currentNodes - nodesChangedInPeriod(events, policy.PeriodSeconds, now, true)
+
+		var policyLimit int32
+		switch policy.Type {
+		case bigtablev2.PercentScalingPolicy:
+			policyLimit = int32(math.Ceil(float64(periodStartNodes) * (1 + float64(policy.Value)/100)))
+		default:
+			policyLimit = periodStartNodes + policy.Value
+		}
+
+		if i == 0 || (selectPolicy == bigtablev2.MaxPolicySelect && policyLimit > limit) ||
+			(selectPolicy == bigtablev2.MinPolicySelect && policyLimit < limit) {
+			limit = policyLimit
+		}
+	}
+
+	// Policies never force the cluster to scale down.
+	if limit < currentNodes {
+		limit = currentNodes
+	}
+
+	return limit, true
+}
+
+func scaleDownLimit(rules *bigtablev2.ScalingRules, events []bigtablev2.ScaleEvent, currentNodes int32, now time.Time) (int32, bool) {
+	if rules == nil {
+		return 0, false
+	}
+
+	selectPolicy := selectedPolicy(rules)
+	if selectPolicy == bigtablev2.DisabledPolicySelect {
+		return currentNodes, true
+	}
+
+	if len(rules.Policies) == 0 {
+		return 0, false
+	}
+
+	var limit int32
+	for i, policy := range rules.Policies {
+		periodStartNodes := currentNodes + nodesChangedInPeriod(events, policy.PeriodSeconds, now, false)
+
+		var policyLimit int32
+		switch policy.Type {
+		case bigtablev2.PercentScalingPolicy:
+			policyLimit = int32(float64(periodStartNodes) * (1 - float64(policy.Value)/100))
+		default:
+			policyLimit = periodStartNodes - policy.Value
+		}
+
+		if i == 0 || (selectPolicy == bigtablev2.MaxPolicySelect && policyLimit < limit) ||
+			(selectPolicy == bigtablev2.MinPolicySelect && policyLimit > limit) {
+			limit = policyLimit
+		}
+	}
+
+	// Policies never force the cluster to scale up.
+	if limit > currentNodes {
+		limit = currentNodes
+	}
+
+	return limit, true
+}
+
+// nodesChangedInPeriod returns the number of nodes added, or removed, within the period ending at now.
+func nodesChangedInPeriod(events []bigtablev2.ScaleEvent, periodSeconds int32, now time.Time, added bool) int32 {
+	periodStart := now.Add(-time.Duration(periodSeconds) * time.Second)
+
+	var changed int32
+	for _, event := range events {
+		if !event.Time.Time.After(periodStart) {
+			continue
+		}
+
+		if added && event.Change > 0 {
+			changed += event.Change
+		} else if !added && event.Change < 0 {
+			changed -= event.Change
+		}
+	}
+
+	return changed
+}
+
+func selectedPolicy(rules *bigtablev2.ScalingRules) bigtablev2.ScalingPolicySelect {
+	if rules.SelectPolicy == nil {
+		return bigtablev2.MaxPolicySelect
+	}
+
+	return *rules.SelectPolicy
+}
+
+func longestPolicyPeriod(spec *bigtablev2.BigtableAutoscalerSpec) time.Duration {
+	var longest int32
+
+	if spec.Behavior != nil {
+		for _, rules := range []*bigtablev2.ScalingRules{spec.Behavior.ScaleUp, spec.Behavior.ScaleDown} {
+			if rules == nil {
+				continue
+			}
+
+			for _, policy := range rules.Policies {
+				if policy.PeriodSeconds > longest {
+					longest = policy.PeriodSeconds
+				}
+			}
+		}
+	}
+
+	return time.Duration(longest) * time.Second
+}
@@ -0,0 +1,155 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+func TestCalcDesiredNodesPolicies(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(secondsAgo int) metav1.Time {
+		return metav1.NewTime(now.Add(-time.Duration(secondsAgo) * time.Second))
+	}
+	rules := func(selectPolicy bigtablev2.ScalingPolicySelect, policies ...bigtablev2.ScalingPolicy) *bigtablev2.ScalingRules {
+		return &bigtablev2.ScalingRules{
+			StabilizationWindowSeconds: pointer.Int32(0),
+			SelectPolicy:               &selectPolicy,
+			Policies:                   policies,
+		}
+	}
+	nodesPolicy := bigtablev2.ScalingPolicy{Type: bigtablev2.NodesScalingPolicy, Value: 2, PeriodSeconds: 60}
+	percentPolicy := bigtablev2.ScalingPolicy{Type: bigtablev2.PercentScalingPolicy, Value: 100, PeriodSeconds: 60}
+
+	tests := map[string]struct {
+		currentNodes int32
+		currentCPU   int32
+		scaleUp      *bigtablev2.ScalingRules
+		scaleDown    *bigtablev2.ScalingRules
+		events       []bigtablev2.ScaleEvent
+		expected     int32
+	}{
+		"no policies": {
+			currentNodes: 3, currentCPU: 100,
+			scaleUp:  rules(bigtablev2.MaxPolicySelect),
+			expected: 6,
+		},
+		"nodes policy limits scale up": {
+			currentNodes: 3, currentCPU: 100,
+			scaleUp:  rules(bigtablev2.MaxPolicySelect, nodesPolicy),
+			expected: 5,
+		},
+		"max select picks the biggest change": {
+			currentNodes: 3, currentCPU: 150,
+			scaleUp:  rules(bigtablev2.MaxPolicySelect, nodesPolicy, percentPolicy),
+			expected: 6,
+		},
+		"min select picks the smallest change": {
+			currentNodes: 3, currentCPU: 150,
+			scaleUp:  rules(bigtablev2.MinPolicySelect, nodesPolicy, percentPolicy),
+			expected: 5,
+		},
+		"scale up within the period counts": {
+			currentNodes: 5, currentCPU: 100,
+			scaleUp:  rules(bigtablev2.MaxPolicySelect, nodesPolicy),
+			events:   []bigtablev2.ScaleEvent{{Time: at(30), Change: 2}},
+			expected: 5,
+		},
+		"scale up before the period is ignored": {
+			currentNodes: 5, currentCPU: 100,
+			scaleUp:  rules(bigtablev2.MaxPolicySelect, nodesPolicy),
+			events:   []bigtablev2.ScaleEvent{{Time: at(90), Change: 2}},
+			expected: 7,
+		},
+		"disabled scale up": {
+			currentNodes: 3, currentCPU: 100,
+			scaleUp:  rules(bigtablev2.DisabledPolicySelect),
+			expected: 3,
+		},
+		"percent policy limits scale down": {
+			currentNodes: 8, currentCPU: 10,
+			scaleDown: rules(bigtablev2.MaxPolicySelect, bigtablev2.ScalingPolicy{Type: bigtablev2.PercentScalingPolicy, Value: 25, PeriodSeconds: 60}),
+			expected:  6,
+		},
+		"disabled scale down": {
+			currentNodes: 8, currentCPU: 10,
+			scaleDown: rules(bigtablev2.DisabledPolicySelect),
+			expected:  8,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := &bigtablev2.BigtableAutoscalerStatus{
+				CurrentNodes:   pointer.Int32(test.currentNodes),
+				CurrentMetrics: []bigtablev2.MetricStatus{cpuStatus(test.currentCPU)},
+				ScaleEvents:    test.events,
+			}
+			spec := &bigtablev2.BigtableAutoscalerSpec{
+				MinNodes:          pointer.Int32(1),
+				MaxNodes:          pointer.Int32(20),
+				MaxScaleDownNodes: pointer.Int32(10),
+				Metrics:           []bigtablev2.MetricSpec{cpuMetric(50)},
+				Behavior: &bigtablev2.BigtableAutoscalerBehavior{
+					ScaleUp:   test.scaleUp,
+					ScaleDown: test.scaleDown,
+				},
+			}
+			if spec.Behavior.ScaleUp == nil {
+				spec.Behavior.ScaleUp = rules(bigtablev2.MaxPolicySelect)
+			}
+			if spec.Behavior.ScaleDown == nil {
+				spec.Behavior.ScaleDown = rules(bigtablev2.MaxPolicySelect)
+			}
+
+			nodes := CalcDesiredNodes(status, spec, now)
+
+			if nodes != test.expected {
+				t.Errorf("expected: %v, got: %v", test.expected, nodes)
+			}
+		})
+	}
+}
+
+func TestAddScaleEvent(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	status := &bigtablev2.BigtableAutoscalerStatus{
+		ScaleEvents: []bigtablev2.ScaleEvent{
+			{Time: metav1.NewTime(now.Add(-90 * time.Second)), Change: 2},
+			{Time: metav1.NewTime(now.Add(-30 * time.Second)), Change: 1},
+		},
+	}
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		Behavior: &bigtablev2.BigtableAutoscalerBehavior{
+			ScaleUp: &bigtablev2.ScalingRules{
+				Policies: []bigtablev2.ScalingPolicy{{Type: bigtablev2.NodesScalingPolicy, Value: 2, PeriodSeconds: 60}},
+			},
+		},
+	}
+
+	AddScaleEvent(status, spec, -1, now)
+
+	if len(status.ScaleEvents) != 2 || status.ScaleEvents[0].Change != 1 || status.ScaleEvents[1].Change != -1 {
+		t.Errorf("unexpected scale events: %v", status.ScaleEvents)
+	}
+}
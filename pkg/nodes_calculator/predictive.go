@@ -0,0 +1,101 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// PredictiveStrategy fits a line, by least squares, through the number of nodes the linear
+// strategy requires at each sample of the history, and recommends the number of nodes the line
+// reaches at the horizon when it is above the number required now. It scales up ahead of a
+// rising load, and otherwise recommends what the linear strategy does, so that it never scales
+// down before the load falls.
+type PredictiveStrategy struct{}
+
+// Recommend implements Strategy.
+func (PredictiveStrategy) Recommend(history []Sample, spec *bigtablev2.BigtableAutoscalerSpec) Recommendation {
+	current := LinearStrategy{}.Recommend(history, spec)
+	newest := history[len(history)-1]
+
+	var minutes, nodes []float64
+	for _, sample := range history {
+		required, driving := calcRequiredNodes(sample.Metrics, sample.Nodes, spec)
+		if driving < 0 {
+			continue
+		}
+
+		minutes = append(minutes, sample.Time.Sub(newest.Time).Minutes())
+		nodes = append(nodes, float64(required))
+	}
+
+	slope, intercept, ok := fitLine(minutes, nodes)
+	if !ok {
+		return current
+	}
+
+	horizon := predictiveHorizon(spec)
+
+	// The epsilon keeps rounding errors from adding a node when the forecast is a whole number.
+	forecast := int32(math.Ceil(intercept + slope*horizon.Minutes() - 1e-9))
+	if forecast <= current.Nodes {
+		return current
+	}
+
+	return Recommendation{
+		Nodes: forecast,
+		Explanation: fmt.Sprintf("the trend of the last %d samples requires %d nodes in %s",
+			len(nodes), forecast, horizon),
+	}
+}
+
+// fitLine returns the slope and intercept of the least squares line through the points, or false
+// when there aren't two points at different times.
+func fitLine(xs, ys []float64) (float64, float64, bool) {
+	n := float64(len(xs))
+
+	var sumX, sumY, sumXX, sumXY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXX += xs[i] * xs[i]
+		sumXY += xs[i] * ys[i]
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if len(xs) < 2 || denominator == 0 {
+		return 0, 0, false
+	}
+
+	slope := (n*sumXY - sumX*sumY) / denominator
+
+	return slope, (sumY - slope*sumX) / n, true
+}
+
+// predictiveHorizon returns how far ahead the trend is followed, with the default of the webhook
+// when it isn't set.
+func predictiveHorizon(spec *bigtablev2.BigtableAutoscalerSpec) time.Duration {
+	if spec.Predictive == nil || spec.Predictive.Horizon == nil {
+		return bigtablev2.DefaultPredictiveHorizon
+	}
+
+	return spec.Predictive.Horizon.Duration
+}
@@ -0,0 +1,87 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+func TestPredictiveStrategy(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		Metrics:    []bigtablev2.MetricSpec{cpuMetric(50)},
+		Predictive: &bigtablev2.PredictiveStrategy{Horizon: &metav1.Duration{Duration: 2 * time.Minute}},
+	}
+	sample := func(minutesAgo, nodes, cpu int32) Sample {
+		return Sample{
+			Time:    now.Add(-time.Duration(minutesAgo) * time.Minute),
+			Nodes:   nodes,
+			Metrics: []bigtablev2.MetricStatus{cpuStatus(cpu)},
+		}
+	}
+
+	tests := map[string]struct {
+		history     []Sample
+		expected    int32
+		explanation string
+	}{
+		"single sample": {
+			history:     []Sample{sample(0, 4, 50)},
+			expected:    4,
+			explanation: "the CPU metric requires 4 nodes",
+		},
+		"rising load": {
+			// 4, 5 and 6 nodes required, one more each minute
+			history:     []Sample{sample(2, 4, 50), sample(1, 4, 62), sample(0, 4, 75)},
+			expected:    8,
+			explanation: "the trend of the last 3 samples requires 8 nodes in 2m0s",
+		},
+		"rising load across a scale up": {
+			history:     []Sample{sample(2, 4, 50), sample(1, 5, 50), sample(0, 6, 50)},
+			expected:    8,
+			explanation: "the trend of the last 3 samples requires 8 nodes in 2m0s",
+		},
+		"falling load": {
+			history:     []Sample{sample(2, 4, 75), sample(1, 4, 62), sample(0, 4, 50)},
+			expected:    4,
+			explanation: "the CPU metric requires 4 nodes",
+		},
+		"samples without metrics are ignored": {
+			history:     []Sample{{Time: now.Add(-time.Minute), Nodes: 4}, sample(0, 4, 75)},
+			expected:    6,
+			explanation: "the CPU metric requires 6 nodes",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			recommendation := PredictiveStrategy{}.Recommend(test.history, spec)
+
+			if recommendation.Nodes != test.expected {
+				t.Errorf("expected: %v, got: %v", test.expected, recommendation.Nodes)
+			}
+			if recommendation.Explanation != test.explanation {
+				t.Errorf("expected explanation %q, got %q", test.explanation, recommendation.Explanation)
+			}
+		})
+	}
+}
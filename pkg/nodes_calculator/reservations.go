@@ -0,0 +1,117 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"math"
+	"time"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+// ApplyReservations returns the spec with minNodes raised to the highest floor of the capacity
+// reservations at now, along with that floor, which is 0 when no reservation is in effect. An
+// unset minNodes counts as 0.
+func ApplyReservations(spec *bigtablev2.BigtableAutoscalerSpec, reservations []bigtablev2.BigtableCapacityReservation,
+	now time.Time) (*bigtablev2.BigtableAutoscalerSpec, int32) {
+	var minNodes int32
+	if spec.MinNodes != nil {
+		minNodes = *spec.MinNodes
+	}
+
+	var reservedNodes int32
+	for i := range reservations {
+		if floor, ok := ReservationFloor(&reservations[i], minNodes, now); ok && floor > reservedNodes {
+			reservedNodes = floor
+		}
+	}
+
+	if reservedNodes <= minNodes {
+		return spec, reservedNodes
+	}
+
+	effective := spec.DeepCopy()
+	effective.MinNodes = pointer.Int32(reservedNodes)
+
+	if effective.MaxNodes != nil && *effective.MaxNodes < reservedNodes {
+		effective.MaxNodes = pointer.Int32(reservedNodes)
+	}
+
+	return effective, reservedNodes
+}
+
+// ReservationFloor returns the number of nodes the reservation keeps the cluster at least at, at
+// now. Within the ramp up lead time, the floor rises linearly from minNodes to the reserved nodes.
+// It returns false before the ramp up and once the reservation is over.
+func ReservationFloor(reservation *bigtablev2.BigtableCapacityReservation, minNodes int32, now time.Time) (int32, bool) {
+	switch ReservationPhase(reservation, now) {
+	case bigtablev2.ActiveCapacityReservationPhase:
+		return reservation.Spec.Nodes, true
+	case bigtablev2.RampingUpCapacityReservationPhase:
+		nodes := reservation.Spec.Nodes
+		if nodes <= minNodes {
+			return nodes, true
+		}
+
+		leadTime := reservation.Spec.RampUpLeadTime.Duration
+		elapsed := now.Sub(reservation.Spec.StartTime.Add(-leadTime))
+		ramp := math.Ceil(float64(nodes-minNodes) * float64(elapsed) / float64(leadTime))
+
+		return minNodes + int32(ramp), true
+	default:
+		return 0, false
+	}
+}
+
+// ReservationPhase returns the stage of the reservation at now, or an empty phase once it is over.
+func ReservationPhase(reservation *bigtablev2.BigtableCapacityReservation, now time.Time) bigtablev2.CapacityReservationPhase {
+	spec := &reservation.Spec
+
+	switch {
+	case !now.Before(spec.EndTime.Time):
+		return ""
+	case !now.Before(spec.StartTime.Time):
+		return bigtablev2.ActiveCapacityReservationPhase
+	case spec.RampUpLeadTime != nil && spec.RampUpLeadTime.Duration > 0 &&
+		!now.Before(spec.StartTime.Add(-spec.RampUpLeadTime.Duration)):
+		return bigtablev2.RampingUpCapacityReservationPhase
+	default:
+		return bigtablev2.PendingCapacityReservationPhase
+	}
+}
+
+// ReservationNextTransition returns when the reservation moves to its next stage after now, or
+// false once it is over.
+func ReservationNextTransition(reservation *bigtablev2.BigtableCapacityReservation, now time.Time) (time.Time, bool) {
+	spec := &reservation.Spec
+
+	switch ReservationPhase(reservation, now) {
+	case bigtablev2.PendingCapacityReservationPhase:
+		if spec.RampUpLeadTime != nil && spec.RampUpLeadTime.Duration > 0 {
+			return spec.StartTime.Add(-spec.RampUpLeadTime.Duration), true
+		}
+
+		return spec.StartTime.Time, true
+	case bigtablev2.RampingUpCapacityReservationPhase:
+		return spec.StartTime.Time, true
+	case bigtablev2.ActiveCapacityReservationPhase:
+		return spec.EndTime.Time, true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,174 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+func TestApplyReservations(t *testing.T) {
+	start := time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC)
+	reservation := func(nodes int32, leadTime time.Duration) bigtablev2.BigtableCapacityReservation {
+		return bigtablev2.BigtableCapacityReservation{
+			Spec: bigtablev2.BigtableCapacityReservationSpec{
+				Nodes:          nodes,
+				StartTime:      metav1.NewTime(start),
+				EndTime:        metav1.NewTime(start.Add(24 * time.Hour)),
+				RampUpLeadTime: &metav1.Duration{Duration: leadTime},
+			},
+		}
+	}
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		MinNodes: pointer.Int32(2),
+		MaxNodes: pointer.Int32(10),
+	}
+
+	tests := map[string]struct {
+		reservations     []bigtablev2.BigtableCapacityReservation
+		now              time.Time
+		expectedReserved int32
+		expectedMinNodes int32
+		expectedMaxNodes int32
+	}{
+		"before the ramp up": {
+			reservations:     []bigtablev2.BigtableCapacityReservation{reservation(12, time.Hour)},
+			now:              start.Add(-2 * time.Hour),
+			expectedMinNodes: 2,
+			expectedMaxNodes: 10,
+		},
+		"halfway through the ramp up": {
+			reservations:     []bigtablev2.BigtableCapacityReservation{reservation(12, time.Hour)},
+			now:              start.Add(-30 * time.Minute),
+			expectedReserved: 7,
+			expectedMinNodes: 7,
+			expectedMaxNodes: 10,
+		},
+		"active reservation above max nodes": {
+			reservations:     []bigtablev2.BigtableCapacityReservation{reservation(12, time.Hour)},
+			now:              start,
+			expectedReserved: 12,
+			expectedMinNodes: 12,
+			expectedMaxNodes: 12,
+		},
+		"highest active reservation wins": {
+			reservations: []bigtablev2.BigtableCapacityReservation{
+				reservation(4, 0),
+				reservation(6, 0),
+			},
+			now:              start.Add(time.Hour),
+			expectedReserved: 6,
+			expectedMinNodes: 6,
+			expectedMaxNodes: 10,
+		},
+		"reservation below min nodes": {
+			reservations:     []bigtablev2.BigtableCapacityReservation{reservation(1, 0)},
+			now:              start,
+			expectedReserved: 1,
+			expectedMinNodes: 2,
+			expectedMaxNodes: 10,
+		},
+		"expired reservation": {
+			reservations:     []bigtablev2.BigtableCapacityReservation{reservation(12, 0)},
+			now:              start.Add(24 * time.Hour),
+			expectedMinNodes: 2,
+			expectedMaxNodes: 10,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			effective, reserved := ApplyReservations(spec, test.reservations, test.now)
+
+			if reserved != test.expectedReserved {
+				t.Errorf("reserved nodes = %d, want %d", reserved, test.expectedReserved)
+			}
+			if *effective.MinNodes != test.expectedMinNodes || *effective.MaxNodes != test.expectedMaxNodes {
+				t.Errorf("limits = %d-%d, want %d-%d", *effective.MinNodes, *effective.MaxNodes,
+					test.expectedMinNodes, test.expectedMaxNodes)
+			}
+		})
+	}
+
+	if *spec.MinNodes != 2 || *spec.MaxNodes != 10 {
+		t.Errorf("the spec was modified")
+	}
+}
+
+func TestApplyReservationsWithoutMinNodes(t *testing.T) {
+	start := time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC)
+	reservations := []bigtablev2.BigtableCapacityReservation{{
+		Spec: bigtablev2.BigtableCapacityReservationSpec{
+			Nodes:          12,
+			StartTime:      metav1.NewTime(start),
+			EndTime:        metav1.NewTime(start.Add(24 * time.Hour)),
+			RampUpLeadTime: &metav1.Duration{Duration: time.Hour},
+		},
+	}}
+	spec := &bigtablev2.BigtableAutoscalerSpec{MaxNodes: pointer.Int32(10)}
+
+	effective, reserved := ApplyReservations(spec, reservations, start.Add(-30*time.Minute))
+
+	if reserved != 6 || effective.MinNodes == nil || *effective.MinNodes != 6 {
+		t.Errorf("reserved nodes = %d, min nodes = %v, want 6", reserved, effective.MinNodes)
+	}
+	if spec.MinNodes != nil {
+		t.Errorf("the spec was modified")
+	}
+}
+
+func TestReservationNextTransition(t *testing.T) {
+	start := time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	reservation := &bigtablev2.BigtableCapacityReservation{
+		Spec: bigtablev2.BigtableCapacityReservationSpec{
+			Nodes:          12,
+			StartTime:      metav1.NewTime(start),
+			EndTime:        metav1.NewTime(end),
+			RampUpLeadTime: &metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	tests := map[string]struct {
+		now           time.Time
+		expectedPhase bigtablev2.CapacityReservationPhase
+		expectedNext  time.Time
+	}{
+		"pending":    {now: start.Add(-2 * time.Hour), expectedPhase: bigtablev2.PendingCapacityReservationPhase, expectedNext: start.Add(-time.Hour)},
+		"ramping up": {now: start.Add(-time.Hour), expectedPhase: bigtablev2.RampingUpCapacityReservationPhase, expectedNext: start},
+		"active":     {now: start, expectedPhase: bigtablev2.ActiveCapacityReservationPhase, expectedNext: end},
+		"over":       {now: end},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if phase := ReservationPhase(reservation, test.now); phase != test.expectedPhase {
+				t.Errorf("phase = %q, want %q", phase, test.expectedPhase)
+			}
+
+			next, ok := ReservationNextTransition(reservation, test.now)
+			if ok != !test.expectedNext.IsZero() || !next.Equal(test.expectedNext) {
+				t.Errorf("next transition = %v, %v, want %v", next, ok, test.expectedNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+// ApplySchedules returns the spec with the overrides of the first schedule active at now, along
+// with that schedule, or the spec itself and nil when no schedule is active.
+func ApplySchedules(spec *bigtablev2.BigtableAutoscalerSpec, now time.Time) (*bigtablev2.BigtableAutoscalerSpec, *bigtablev2.Schedule) {
+	for i := range spec.Schedules {
+		schedule := &spec.Schedules[i]
+		if !scheduleActive(schedule, now) {
+			continue
+		}
+
+		effective := spec.DeepCopy()
+
+		if schedule.MinNodes != nil {
+			effective.MinNodes = pointer.Int32(*schedule.MinNodes)
+		}
+
+		if schedule.MaxNodes != nil {
+			effective.MaxNodes = pointer.Int32(*schedule.MaxNodes)
+		}
+
+		// Capacity provisioned ahead of a peak must not be capped by the usual maxNodes.
+		if effective.MinNodes != nil && effective.MaxNodes != nil && *effective.MinNodes > *effective.MaxNodes {
+			effective.MaxNodes = pointer.Int32(*effective.MinNodes)
+		}
+
+		if schedule.TargetCPUUtilization != nil {
+			for j := range effective.Metrics {
+				if effective.Metrics[j].Type == bigtablev2.CPUMetricSourceType {
+					effective.Metrics[j].Target.AverageUtilization = pointer.Int32(*schedule.TargetCPUUtilization)
+				}
+			}
+		}
+
+		return effective, schedule
+	}
+
+	return spec, nil
+}
+
+// scheduleActive tells whether the schedule started within its duration before now.
+func scheduleActive(schedule *bigtablev2.Schedule, now time.Time) bool {
+	return cronActive(schedule.Cron, schedule.TimeZone, schedule.Duration.Duration, now)
+}
+
+// cronActive tells whether a period starting at the times of the cron expression, in the time
+// zone, started within duration before now. Expressions that can't be parsed, which the webhook
+// rejects, are never active.
+func cronActive(expression, timeZone string, duration time.Duration, now time.Time) bool {
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return false
+	}
+
+	cronSchedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return false
+	}
+
+	start := cronSchedule.Next(now.Add(-duration).In(location))
+
+	return !start.IsZero() && !start.After(now)
+}
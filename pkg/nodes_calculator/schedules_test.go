@@ -0,0 +1,126 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+func TestApplySchedules(t *testing.T) {
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		MinNodes: pointer.Int32(1),
+		MaxNodes: pointer.Int32(10),
+		Metrics: []bigtablev2.MetricSpec{
+			{
+				Type: bigtablev2.CPUMetricSourceType,
+				Target: bigtablev2.MetricTarget{
+					Type:               bigtablev2.UtilizationMetricType,
+					AverageUtilization: pointer.Int32(50),
+				},
+			},
+		},
+		Schedules: []bigtablev2.Schedule{
+			{
+				Name:                 "morning-peak",
+				Cron:                 "30 6 * * 1-5",
+				TimeZone:             "America/Sao_Paulo",
+				Duration:             metav1.Duration{Duration: 3 * time.Hour},
+				MinNodes:             pointer.Int32(12),
+				TargetCPUUtilization: pointer.Int32(40),
+			},
+			{
+				Name:     "business-hours",
+				Cron:     "0 9 * * 1-5",
+				Duration: metav1.Duration{Duration: 9 * time.Hour},
+				MaxNodes: pointer.Int32(20),
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		now              time.Time
+		expectedSchedule string
+		expectedMinNodes int32
+		expectedMaxNodes int32
+		expectedCPU      int32
+	}{
+		"before any schedule": {
+			now:              time.Date(2021, 5, 3, 8, 30, 0, 0, time.UTC),
+			expectedMinNodes: 1,
+			expectedMaxNodes: 10,
+			expectedCPU:      50,
+		},
+		"in the time zone of the schedule": {
+			now:              time.Date(2021, 5, 3, 9, 30, 0, 0, time.UTC),
+			expectedSchedule: "morning-peak",
+			expectedMinNodes: 12,
+			expectedMaxNodes: 12,
+			expectedCPU:      40,
+		},
+		"first active schedule wins": {
+			now:              time.Date(2021, 5, 3, 12, 0, 0, 0, time.UTC),
+			expectedSchedule: "morning-peak",
+			expectedMinNodes: 12,
+			expectedMaxNodes: 12,
+			expectedCPU:      40,
+		},
+		"after the duration of a schedule": {
+			now:              time.Date(2021, 5, 3, 12, 30, 0, 0, time.UTC),
+			expectedSchedule: "business-hours",
+			expectedMinNodes: 1,
+			expectedMaxNodes: 20,
+			expectedCPU:      50,
+		},
+		"on the weekend": {
+			now:              time.Date(2021, 5, 8, 12, 0, 0, 0, time.UTC),
+			expectedMinNodes: 1,
+			expectedMaxNodes: 10,
+			expectedCPU:      50,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			effective, schedule := ApplySchedules(spec, test.now)
+
+			scheduleName := ""
+			if schedule != nil {
+				scheduleName = schedule.Name
+			}
+			if scheduleName != test.expectedSchedule {
+				t.Errorf("schedule = %q, want %q", scheduleName, test.expectedSchedule)
+			}
+			if *effective.MinNodes != test.expectedMinNodes || *effective.MaxNodes != test.expectedMaxNodes {
+				t.Errorf("limits = %d-%d, want %d-%d", *effective.MinNodes, *effective.MaxNodes,
+					test.expectedMinNodes, test.expectedMaxNodes)
+			}
+			if cpu := *effective.Metrics[0].Target.AverageUtilization; cpu != test.expectedCPU {
+				t.Errorf("CPU target = %d, want %d", cpu, test.expectedCPU)
+			}
+		})
+	}
+
+	if *spec.MinNodes != 1 || *spec.Metrics[0].Target.AverageUtilization != 50 {
+		t.Errorf("the spec was modified")
+	}
+}
@@ -0,0 +1,103 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// AddRecommendation records the number of nodes required at now. A recommendation is only
+// added when it differs from the previous one, and recommendations that no longer affect
+// any stabilization window are dropped.
+func AddRecommendation(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec, nodes int32, now time.Time) {
+	recommendations := status.Recommendations
+
+	if len(recommendations) == 0 || recommendations[len(recommendations)-1].Nodes != nodes {
+		recommendations = append(recommendations, bigtablev2.Recommendation{
+			Time:  metav1.NewTime(now),
+			Nodes: nodes,
+		})
+	}
+
+	upWindow, downWindow := stabilizationWindows(spec)
+	windowStart := now.Add(-maxDuration(upWindow, downWindow))
+
+	// The last recommendation made before the window still stands at its start, so it is kept.
+	first := 0
+	for first < len(recommendations)-1 && !recommendations[first+1].Time.Time.After(windowStart) {
+		first++
+	}
+
+	status.Recommendations = append([]bigtablev2.Recommendation(nil), recommendations[first:]...)
+}
+
+// CalcStabilizedRecommendations returns the lowest recommendation within the scale up window and
+// the highest one within the scale down window. Both include the number of nodes currently required.
+func CalcStabilizedRecommendations(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec, now time.Time) (int32, int32) {
+	requiredNodes := CalcRequiredNodes(status, spec)
+	upWindow, downWindow := stabilizationWindows(spec)
+
+	scaleUp := requiredNodes
+	scaleDown := requiredNodes
+
+	for i, recommendation := range status.Recommendations {
+		// A recommendation stands until the next one is made.
+		end := now
+		if i+1 < len(status.Recommendations) {
+			end = status.Recommendations[i+1].Time.Time
+		}
+
+		if end.After(now.Add(-upWindow)) && recommendation.Nodes < scaleUp {
+			scaleUp = recommendation.Nodes
+		}
+
+		if end.After(now.Add(-downWindow)) && recommendation.Nodes > scaleDown {
+			scaleDown = recommendation.Nodes
+		}
+	}
+
+	return scaleUp, scaleDown
+}
+
+func stabilizationWindows(spec *bigtablev2.BigtableAutoscalerSpec) (time.Duration, time.Duration) {
+	upWindow := bigtablev2.DefaultScaleUpStabilizationWindowSeconds
+	downWindow := bigtablev2.DefaultScaleDownStabilizationWindowSeconds
+
+	if spec.Behavior != nil {
+		if rules := spec.Behavior.ScaleUp; rules != nil && rules.StabilizationWindowSeconds != nil {
+			upWindow = *rules.StabilizationWindowSeconds
+		}
+
+		if rules := spec.Behavior.ScaleDown; rules != nil && rules.StabilizationWindowSeconds != nil {
+			downWindow = *rules.StabilizationWindowSeconds
+		}
+	}
+
+	return time.Duration(upWindow) * time.Second, time.Duration(downWindow) * time.Second
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+
+	return b
+}
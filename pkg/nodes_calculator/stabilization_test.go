@@ -0,0 +1,169 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+)
+
+func behavior(upWindow, downWindow int32) *bigtablev2.BigtableAutoscalerBehavior {
+	return &bigtablev2.BigtableAutoscalerBehavior{
+		ScaleUp:   &bigtablev2.ScalingRules{StabilizationWindowSeconds: pointer.Int32(upWindow)},
+		ScaleDown: &bigtablev2.ScalingRules{StabilizationWindowSeconds: pointer.Int32(downWindow)},
+	}
+}
+
+func TestAddRecommendation(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(secondsAgo int) metav1.Time {
+		return metav1.NewTime(now.Add(-time.Duration(secondsAgo) * time.Second))
+	}
+
+	tests := map[string]struct {
+		recommendations []bigtablev2.Recommendation
+		nodes           int32
+		expected        []bigtablev2.Recommendation
+	}{
+		"first recommendation": {
+			nodes:    3,
+			expected: []bigtablev2.Recommendation{{Time: at(0), Nodes: 3}},
+		},
+		"same as previous": {
+			recommendations: []bigtablev2.Recommendation{{Time: at(30), Nodes: 3}},
+			nodes:           3,
+			expected:        []bigtablev2.Recommendation{{Time: at(30), Nodes: 3}},
+		},
+		"different from previous": {
+			recommendations: []bigtablev2.Recommendation{{Time: at(30), Nodes: 3}},
+			nodes:           4,
+			expected:        []bigtablev2.Recommendation{{Time: at(30), Nodes: 3}, {Time: at(0), Nodes: 4}},
+		},
+		"drops recommendations out of the windows": {
+			recommendations: []bigtablev2.Recommendation{
+				{Time: at(900), Nodes: 2},
+				{Time: at(700), Nodes: 5},
+				{Time: at(100), Nodes: 4},
+			},
+			nodes: 3,
+			expected: []bigtablev2.Recommendation{
+				{Time: at(700), Nodes: 5},
+				{Time: at(100), Nodes: 4},
+				{Time: at(0), Nodes: 3},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := &bigtablev2.BigtableAutoscalerStatus{Recommendations: test.recommendations}
+			spec := &bigtablev2.BigtableAutoscalerSpec{Behavior: behavior(0, 600)}
+
+			AddRecommendation(status, spec, test.nodes, now)
+
+			if len(status.Recommendations) != len(test.expected) {
+				t.Fatalf("expected: %v, got: %v", test.expected, status.Recommendations)
+			}
+			for i := range test.expected {
+				if !status.Recommendations[i].Time.Equal(&test.expected[i].Time) || status.Recommendations[i].Nodes != test.expected[i].Nodes {
+					t.Errorf("expected: %v, got: %v", test.expected, status.Recommendations)
+				}
+			}
+		})
+	}
+}
+
+func TestCalcDesiredNodesStabilization(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(secondsAgo int) metav1.Time {
+		return metav1.NewTime(now.Add(-time.Duration(secondsAgo) * time.Second))
+	}
+
+	tests := map[string]struct {
+		defaultBehavior bool
+		upWindow        int32
+		downWindow      int32
+		recommendations []bigtablev2.Recommendation
+		currentCPU      int32
+		expected        int32
+	}{
+		"scales up right away without up window": {
+			upWindow:        0,
+			downWindow:      1800,
+			recommendations: []bigtablev2.Recommendation{{Time: at(10), Nodes: 4}},
+			currentCPU:      100,
+			expected:        8,
+		},
+		"scales up right away by default": {
+			defaultBehavior: true,
+			recommendations: []bigtablev2.Recommendation{{Time: at(10), Nodes: 4}},
+			currentCPU:      100,
+			expected:        8,
+		},
+		"scales up to the lowest recommendation of the up window": {
+			upWindow:        60,
+			downWindow:      60,
+			recommendations: []bigtablev2.Recommendation{{Time: at(150), Nodes: 4}, {Time: at(90), Nodes: 6}},
+			currentCPU:      100,
+			expected:        6,
+		},
+		"holds scale down within the down window": {
+			upWindow:        0,
+			downWindow:      1800,
+			recommendations: []bigtablev2.Recommendation{{Time: at(1000), Nodes: 4}, {Time: at(600), Nodes: 2}},
+			currentCPU:      10,
+			expected:        4,
+		},
+		"scales down to the highest recommendation of the down window": {
+			upWindow:        0,
+			downWindow:      300,
+			recommendations: []bigtablev2.Recommendation{{Time: at(1000), Nodes: 4}, {Time: at(400), Nodes: 3}},
+			currentCPU:      10,
+			expected:        3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status := &bigtablev2.BigtableAutoscalerStatus{
+				CurrentNodes:    pointer.Int32(4),
+				CurrentMetrics:  []bigtablev2.MetricStatus{cpuStatus(test.currentCPU)},
+				Recommendations: test.recommendations,
+			}
+			spec := &bigtablev2.BigtableAutoscalerSpec{
+				MinNodes:          pointer.Int32(1),
+				MaxNodes:          pointer.Int32(10),
+				MaxScaleDownNodes: pointer.Int32(4),
+				Metrics:           []bigtablev2.MetricSpec{cpuMetric(50)},
+			}
+			if !test.defaultBehavior {
+				spec.Behavior = behavior(test.upWindow, test.downWindow)
+			}
+
+			nodes := CalcDesiredNodes(status, spec, now)
+
+			if nodes != test.expected {
+				t.Errorf("expected: %v, got: %v", test.expected, nodes)
+			}
+		})
+	}
+}
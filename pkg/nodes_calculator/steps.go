@@ -0,0 +1,86 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"fmt"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+// StepsStrategy adds or removes the nodes of the step whose threshold the metric requiring the
+// most nodes crossed, like the step scaling policies of the cloud providers. The current number
+// of nodes is kept while the metric stays between the highest threshold below its target and the
+// lowest one above it.
+type StepsStrategy struct{}
+
+// Recommend implements Strategy. Only the newest sample is used.
+func (StepsStrategy) Recommend(history []Sample, spec *bigtablev2.BigtableAutoscalerSpec) Recommendation {
+	sample := history[len(history)-1]
+
+	// The PID error is the relative distance of the metric requiring the most nodes to its target.
+	errorValue, driving := calcPIDError(sample.Metrics, sample.Nodes, spec)
+	if driving < 0 {
+		return Recommendation{Nodes: sample.Nodes, Explanation: "no metric has a current value"}
+	}
+
+	percent := (1 + errorValue) * 100
+	metricType := spec.Metrics[driving].Type
+
+	step, ok := crossedStep(spec.Steps, percent)
+	if !ok {
+		return Recommendation{
+			Nodes:       sample.Nodes,
+			Explanation: fmt.Sprintf("the %s metric is at %.0f%% of its target, within the steps", metricType, percent),
+		}
+	}
+
+	nodes := sample.Nodes + step.Change
+	if nodes < 0 {
+		nodes = 0
+	}
+
+	return Recommendation{
+		Nodes: nodes,
+		Explanation: fmt.Sprintf("the %s metric is at %.0f%% of its target, past the %d%% step of %+d nodes",
+			metricType, percent, step.Threshold, step.Change),
+	}
+}
+
+// crossedStep returns the step whose threshold is the farthest from the target among those the
+// metric, in percent of its target, crossed, or false when it crossed none.
+func crossedStep(steps []bigtablev2.ScalingStep, percent float64) (bigtablev2.ScalingStep, bool) {
+	var crossed bigtablev2.ScalingStep
+	found := false
+
+	for _, step := range steps {
+		threshold := float64(step.Threshold)
+
+		switch {
+		case step.Threshold > 100 && percent >= threshold:
+			if !found || step.Threshold > crossed.Threshold {
+				crossed, found = step, true
+			}
+		case step.Threshold < 100 && percent <= threshold:
+			if !found || step.Threshold < crossed.Threshold {
+				crossed, found = step, true
+			}
+		}
+	}
+
+	return crossed, found
+}
@@ -0,0 +1,95 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+func TestStepsStrategy(t *testing.T) {
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		Metrics: []bigtablev2.MetricSpec{cpuMetric(50)},
+		Steps: []bigtablev2.ScalingStep{
+			{Threshold: 120, Change: 1},
+			{Threshold: 160, Change: 4},
+			{Threshold: 80, Change: -1},
+			{Threshold: 40, Change: -2},
+		},
+	}
+
+	tests := map[string]struct {
+		currentCPU  int32
+		expected    int32
+		explanation string
+	}{
+		"within the steps": {
+			currentCPU:  55,
+			expected:    4,
+			explanation: "the CPU metric is at 110% of its target, within the steps",
+		},
+		"first step up": {
+			currentCPU:  60,
+			expected:    5,
+			explanation: "the CPU metric is at 120% of its target, past the 120% step of +1 nodes",
+		},
+		"highest step up wins": {
+			currentCPU:  90,
+			expected:    8,
+			explanation: "the CPU metric is at 180% of its target, past the 160% step of +4 nodes",
+		},
+		"first step down": {
+			currentCPU:  35,
+			expected:    3,
+			explanation: "the CPU metric is at 70% of its target, past the 80% step of -1 nodes",
+		},
+		"lowest step down wins": {
+			currentCPU:  10,
+			expected:    2,
+			explanation: "the CPU metric is at 20% of its target, past the 40% step of -2 nodes",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sample := Sample{Nodes: 4, Metrics: []bigtablev2.MetricStatus{cpuStatus(test.currentCPU)}}
+
+			recommendation := StepsStrategy{}.Recommend([]Sample{sample}, spec)
+
+			if recommendation.Nodes != test.expected {
+				t.Errorf("expected: %v, got: %v", test.expected, recommendation.Nodes)
+			}
+			if recommendation.Explanation != test.explanation {
+				t.Errorf("expected explanation %q, got %q", test.explanation, recommendation.Explanation)
+			}
+		})
+	}
+}
+
+func TestStepsStrategyWithoutMetrics(t *testing.T) {
+	spec := &bigtablev2.BigtableAutoscalerSpec{
+		Metrics: []bigtablev2.MetricSpec{cpuMetric(50)},
+		Steps:   []bigtablev2.ScalingStep{{Threshold: 120, Change: 1}},
+	}
+
+	recommendation := StepsStrategy{}.Recommend([]Sample{{Nodes: 4}}, spec)
+
+	if recommendation.Nodes != 4 {
+		t.Errorf("expected the current nodes to be kept, got: %v", recommendation.Nodes)
+	}
+}
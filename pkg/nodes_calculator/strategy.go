@@ -0,0 +1,197 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+const (
+	// SampleHistory is how far back the samples handed to the strategies go.
+	SampleHistory = 10 * time.Minute
+
+	// SampleInterval is the shortest time between two samples kept in the status. The syncer
+	// reads the metrics more often, but only the newest of those samples is handed over.
+	SampleInterval = 1 * time.Minute
+)
+
+// Sample is the state of the cluster at the time its metrics were read.
+type Sample struct {
+	Time    time.Time
+	Nodes   int32
+	Metrics []bigtablev2.MetricStatus
+
+	// PID is the state of the PID strategy once the sample was folded in. It is only set on the
+	// newest sample.
+	PID *bigtablev2.PIDStatus
+}
+
+// Recommendation is the number of nodes a strategy asks for, with a human readable explanation
+// of what it follows.
+type Recommendation struct {
+	Nodes       int32
+	Explanation string
+}
+
+// Strategy recommends the number of nodes required by the samples read within SampleHistory,
+// oldest first, before the stabilization windows, the scaling policies and the limits are applied.
+// The history always ends with the newest sample, so it is never empty. Recommend is called
+// several times per reconcile, so it must not have side effects.
+type Strategy interface {
+	Recommend(history []Sample, spec *bigtablev2.BigtableAutoscalerSpec) Recommendation
+}
+
+// StatefulStrategy is a Strategy keeping state in the status between samples, so that it
+// survives restarts of the operator and changes of leader.
+type StatefulStrategy interface {
+	Strategy
+
+	// Advance folds the newest sample into the state kept in the status. Samples already
+	// folded in are ignored, so it can be called more than once per sample.
+	Advance(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec)
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[bigtablev2.ScalingStrategy]Strategy{}
+)
+
+func init() {
+	RegisterStrategy(bigtablev2.LinearScalingStrategy, LinearStrategy{})
+	RegisterStrategy(bigtablev2.PIDScalingStrategy, PIDStrategy{})
+	RegisterStrategy(bigtablev2.StepsScalingStrategy, StepsStrategy{})
+	RegisterStrategy(bigtablev2.PredictiveScalingStrategy, PredictiveStrategy{})
+}
+
+// RegisterStrategy makes a strategy selectable by spec.strategy. It panics when a strategy is
+// already registered under the same name.
+func RegisterStrategy(name bigtablev2.ScalingStrategy, strategy Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+
+	if _, ok := strategies[name]; ok {
+		panic(fmt.Sprintf("scaling strategy %q registered twice", name))
+	}
+
+	strategies[name] = strategy
+}
+
+// LookupStrategy returns the strategy registered under the name. An empty name selects the
+// linear strategy.
+func LookupStrategy(name bigtablev2.ScalingStrategy) (Strategy, bool) {
+	if name == "" {
+		name = bigtablev2.LinearScalingStrategy
+	}
+
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	strategy, ok := strategies[name]
+
+	return strategy, ok
+}
+
+// CurrentSample returns the newest sample of the autoscaler.
+func CurrentSample(status *bigtablev2.BigtableAutoscalerStatus) Sample {
+	sample := Sample{Metrics: status.CurrentMetrics, PID: status.PID}
+	if status.CurrentNodes != nil {
+		sample.Nodes = *status.CurrentNodes
+	}
+	if status.LastFetchTime != nil {
+		sample.Time = status.LastFetchTime.Time
+	}
+
+	return sample
+}
+
+// History returns the samples kept in the status followed by the newest sample, oldest first.
+func History(status *bigtablev2.BigtableAutoscalerStatus) []Sample {
+	current := CurrentSample(status)
+
+	history := make([]Sample, 0, len(status.Samples)+1)
+	for _, sample := range status.Samples {
+		if !sample.Time.Time.Before(current.Time) {
+			break
+		}
+
+		history = append(history, Sample{Time: sample.Time.Time, Nodes: sample.Nodes, Metrics: sample.Metrics})
+	}
+
+	return append(history, current)
+}
+
+// AddSample keeps the newest sample in the status when it was read at least SampleInterval after
+// the last one kept, and drops the samples read more than SampleHistory before it.
+func AddSample(status *bigtablev2.BigtableAutoscalerStatus) {
+	current := CurrentSample(status)
+	if current.Time.IsZero() {
+		return
+	}
+
+	samples := status.Samples
+	if n := len(samples); n == 0 || !current.Time.Before(samples[n-1].Time.Add(SampleInterval)) {
+		samples = append(samples, bigtablev2.MetricSample{
+			Time:    metav1.NewTime(current.Time),
+			Nodes:   current.Nodes,
+			Metrics: current.Metrics,
+		})
+	}
+
+	historyStart := current.Time.Add(-SampleHistory)
+
+	first := 0
+	for first < len(samples) && samples[first].Time.Time.Before(historyStart) {
+		first++
+	}
+
+	status.Samples = append([]bigtablev2.MetricSample(nil), samples[first:]...)
+}
+
+// Recommend returns the recommendation of the strategy selected by the spec, or an error when it
+// isn't registered, e.g. when the spec was written for a newer version of the operator.
+func Recommend(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec) (Recommendation, error) {
+	strategy, ok := LookupStrategy(spec.Strategy)
+	if !ok {
+		return Recommendation{}, fmt.Errorf("scaling strategy %q is not registered", spec.Strategy)
+	}
+
+	return strategy.Recommend(History(status), spec), nil
+}
+
+// AdvanceStrategy folds the newest sample into the state of the strategy selected by the spec,
+// and drops the state kept by a strategy that is no longer selected. It is called before
+// Recommend, once per reconcile. Nothing is folded in when the strategy isn't registered.
+func AdvanceStrategy(status *bigtablev2.BigtableAutoscalerStatus, spec *bigtablev2.BigtableAutoscalerSpec) {
+	if spec.Strategy != bigtablev2.PIDScalingStrategy {
+		status.PID = nil
+	}
+
+	strategy, ok := LookupStrategy(spec.Strategy)
+	if !ok {
+		return
+	}
+
+	if stateful, ok := strategy.(StatefulStrategy); ok {
+		stateful.Advance(status, spec)
+	}
+}
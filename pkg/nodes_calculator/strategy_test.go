@@ -0,0 +1,135 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes_calculator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
+
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+)
+
+type fixedStrategy int32
+
+func (s fixedStrategy) Recommend(history []Sample, spec *bigtablev2.BigtableAutoscalerSpec) Recommendation {
+	return Recommendation{Nodes: int32(s), Explanation: "fixed"}
+}
+
+func TestLookupStrategy(t *testing.T) {
+	if strategy, ok := LookupStrategy(""); !ok || strategy != (LinearStrategy{}) {
+		t.Errorf("expected the linear strategy by default, got: %v", strategy)
+	}
+
+	if _, ok := LookupStrategy("Unknown"); ok {
+		t.Errorf("expected no strategy registered as Unknown")
+	}
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	RegisterStrategy("Fixed", fixedStrategy(9))
+	defer func() {
+		strategiesMu.Lock()
+		delete(strategies, "Fixed")
+		strategiesMu.Unlock()
+	}()
+
+	status := &bigtablev2.BigtableAutoscalerStatus{CurrentNodes: pointer.Int32(4)}
+	spec := &bigtablev2.BigtableAutoscalerSpec{Strategy: "Fixed"}
+
+	if recommendation, err := Recommend(status, spec); err != nil || recommendation.Nodes != 9 {
+		t.Errorf("expected: 9, got: %v (%v)", recommendation.Nodes, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a strategy twice to panic")
+		}
+	}()
+	RegisterStrategy("Fixed", fixedStrategy(3))
+}
+
+func TestRecommendUnknownStrategy(t *testing.T) {
+	status := &bigtablev2.BigtableAutoscalerStatus{
+		CurrentNodes:   pointer.Int32(4),
+		CurrentMetrics: []bigtablev2.MetricStatus{cpuStatus(80)},
+	}
+	spec := &bigtablev2.BigtableAutoscalerSpec{Strategy: "Unknown", Metrics: []bigtablev2.MetricSpec{cpuMetric(50)}}
+
+	if _, err := Recommend(status, spec); err == nil {
+		t.Errorf("expected an error for an unknown strategy")
+	}
+
+	if nodes := CalcRequiredNodes(status, spec); nodes != 4 {
+		t.Errorf("expected the current nodes to be kept, got: %v", nodes)
+	}
+}
+
+func TestAddSample(t *testing.T) {
+	start := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	status := &bigtablev2.BigtableAutoscalerStatus{CurrentNodes: pointer.Int32(4)}
+
+	// the syncer reads the metrics every 5 seconds for 12 minutes
+	for elapsed := time.Duration(0); elapsed <= 12*time.Minute; elapsed += 5 * time.Second {
+		status.LastFetchTime = &metav1.Time{Time: start.Add(elapsed)}
+		status.CurrentMetrics = []bigtablev2.MetricStatus{cpuStatus(int32(elapsed / time.Minute))}
+		AddSample(status)
+	}
+
+	if len(status.Samples) != 11 {
+		t.Fatalf("expected 11 samples, one per minute within the history, got: %v", len(status.Samples))
+	}
+
+	for i, sample := range status.Samples {
+		if expected := start.Add(time.Duration(i+2) * time.Minute); !sample.Time.Time.Equal(expected) {
+			t.Errorf("expected sample %d at %v, got: %v", i, expected, sample.Time)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	status := &bigtablev2.BigtableAutoscalerStatus{
+		CurrentNodes:   pointer.Int32(5),
+		CurrentMetrics: []bigtablev2.MetricStatus{cpuStatus(70)},
+		LastFetchTime:  &metav1.Time{Time: now},
+		Samples: []bigtablev2.MetricSample{
+			{Time: metav1.NewTime(now.Add(-time.Minute)), Nodes: 4, Metrics: []bigtablev2.MetricStatus{cpuStatus(60)}},
+			{Time: metav1.NewTime(now), Nodes: 5, Metrics: []bigtablev2.MetricStatus{cpuStatus(70)}},
+		},
+		PID: &bigtablev2.PIDStatus{LastSampleTime: metav1.NewTime(now)},
+	}
+
+	history := History(status)
+
+	if len(history) != 2 {
+		t.Fatalf("expected the kept sample followed by the newest one, got: %v", history)
+	}
+	if history[0].Nodes != 4 || history[0].PID != nil {
+		t.Errorf("expected the kept sample first, got: %v", history[0])
+	}
+	if history[1].Nodes != 5 || !history[1].Time.Equal(now) || history[1].PID == nil {
+		t.Errorf("expected the newest sample last, got: %v", history[1])
+	}
+
+	if history := History(&bigtablev2.BigtableAutoscalerStatus{}); len(history) != 1 {
+		t.Errorf("expected the newest sample alone without samples kept, got: %v", history)
+	}
+}
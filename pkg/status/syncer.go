@@ -3,14 +3,22 @@ package status
 import (
 	"context"
 	"fmt"
+	"math"
 	"strings"
+	"sync"
 	"time"
 
-	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
+	"bigtable-autoscaler.com/m/v2/pkg/conditions"
 	"bigtable-autoscaler.com/m/v2/pkg/googlecloud"
+	"bigtable-autoscaler.com/m/v2/pkg/nodes_calculator"
 	"github.com/go-logr/logr"
 	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 )
 
 const optimisticLockError = "the object has been modified; please apply your changes to the latest version and try again"
@@ -18,57 +26,69 @@ const inexistentResourceError = "invalid object"
 const tickTime = 5 * time.Second
 
 type Syncer struct {
-	writer  Writer
-	running map[types.UID]chan bool
-	log     logr.Logger
+	writer   Writer
+	recorder record.EventRecorder
+	mu       sync.Mutex
+	running  map[types.UID]*routine
+	log      logr.Logger
 }
 
-func NewSyncer(writer Writer, log logr.Logger) *Syncer {
+// routine is a running metrics sync routine.
+type routine struct {
+	stop chan bool
+	done chan struct{}
+}
+
+// interrupt stops the routine and waits for it to return, so that its Google Cloud client can be
+// closed once interrupt returns.
+func (r *routine) interrupt() {
+	r.stop <- true
+	<-r.done
+}
+
+func NewSyncer(writer Writer, recorder record.EventRecorder, log logr.Logger) *Syncer {
 	return &Syncer{
-		writer:  writer,
-		running: make(map[types.UID]chan bool),
-		log:     log,
+		writer:   writer,
+		recorder: recorder,
+		running:  make(map[types.UID]*routine),
+		log:      log,
 	}
 }
 
+// Register starts the metrics sync routine of the autoscaler, after stopping and waiting for the
+// previous one, if any. The routine works on its own copy of the autoscaler, so the caller keeps
+// using its own: later changes reach the routine through the next Register.
 func (s *Syncer) Register(
 	ctx context.Context,
-	autoscaler *bigtablev1.BigtableAutoscaler,
+	autoscaler *bigtablev2.BigtableAutoscaler,
 	googleCloudClient googlecloud.GoogleCloudClient,
 ) {
-	if previous_ch, ok := s.running[autoscaler.UID]; ok {
+	autoscaler = autoscaler.DeepCopy()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, ok := s.running[autoscaler.UID]; ok {
 		s.log.Info("Stopping previous routine")
-		previous_ch <- true
+		previous.interrupt()
 	}
 
-	ch := make(chan bool, 1)
-	s.running[autoscaler.UID] = ch
+	current := &routine{stop: make(chan bool, 1), done: make(chan struct{})}
+	s.running[autoscaler.UID] = current
 
 	eg, ctx := errgroup.WithContext(ctx)
 	eg.Go(func() error {
+		defer close(current.done)
+
 		ticker := time.NewTicker(tickTime)
+		defer ticker.Stop()
 		s.log.Info("Starting new metrics sync routine")
 
 		for {
 			select {
 			case <-ticker.C:
-				currentCpu, err := googleCloudClient.GetCurrentCPULoad()
-				if err != nil {
-					s.log.Error(err, "failed to get nodes metrics")
-
-					continue
-				}
-				autoscaler.Status.CurrentCPUUtilization = &currentCpu
-
-				currentNodes, err := googleCloudClient.GetCurrentNodeCount(autoscaler.Spec.BigtableClusterRef.ClusterID)
-				if err != nil {
-					s.log.Error(err, "failed to get nodes count")
-
-					continue
-				}
-
-				autoscaler.Status.CurrentNodes = &currentNodes
-				s.log.Info("Metric read", "cpu utilization", currentCpu, "node count", currentNodes, "autoscaler", autoscaler.ObjectMeta.Name)
+				s.syncMetrics(autoscaler, googleCloudClient)
+				conditions.SetReady(&autoscaler.Status.Conditions, autoscaler.Generation)
 
 				if err := s.writer.Update(ctx, autoscaler); err != nil {
 					if strings.Contains(err.Error(), inexistentResourceError) {
@@ -86,7 +106,7 @@ func (s *Syncer) Register(
 					return fmt.Errorf("failed to update autoscaler status: %w", err)
 				}
 
-			case <-ch:
+			case <-current.stop:
 				s.log.Info("Interrupted sync from previous version")
 				return nil
 
@@ -94,3 +114,175 @@ func (s *Syncer) Register(
 		}
 	})
 }
+
+// Unregister stops the metrics sync routine of the autoscaler, and reports whether one was running.
+// The routine has returned by the time Unregister does.
+func (s *Syncer) Unregister(uid types.UID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.running[uid]
+	if !ok {
+		return false
+	}
+
+	s.log.Info("Stopping metrics sync routine", "autoscaler", uid)
+	current.interrupt()
+	delete(s.running, uid)
+
+	return true
+}
+
+// metricFailureReasons are the MetricsAvailable reasons used when fetching a metric fails.
+var metricFailureReasons = map[bigtablev2.MetricSourceType]string{
+	bigtablev2.CPUMetricSourceType:            "FailedGetCPULoad",
+	bigtablev2.HottestNodeCPUMetricSourceType: "FailedGetHottestNodeCPULoad",
+	bigtablev2.StorageMetricSourceType:        "FailedGetStorageUtilization",
+	bigtablev2.ExternalMetricSourceType:       "FailedGetExternalMetric",
+}
+
+func (s *Syncer) syncMetrics(autoscaler *bigtablev2.BigtableAutoscaler, googleCloudClient googlecloud.GoogleCloudClient) {
+	currentMetrics := make([]bigtablev2.MetricStatus, 0, len(autoscaler.Spec.Metrics))
+
+	for _, metric := range autoscaler.Spec.Metrics {
+		current, err := fetchMetric(metric, queryOptions(&autoscaler.Spec, metric.Aggregation), googleCloudClient)
+		if err != nil {
+			s.log.Error(err, "failed to get metric", "type", metric.Type)
+			s.setMetricsUnavailable(autoscaler, metricFailureReasons[metric.Type], err)
+
+			return
+		}
+
+		currentMetrics = append(currentMetrics, bigtablev2.MetricStatus{
+			Type:     metric.Type,
+			External: metric.External,
+			Current:  current,
+		})
+	}
+
+	storageOpts := queryOptions(&autoscaler.Spec, bigtablev2.LatestMetricAggregation)
+	storageUtilization, err := currentStorageUtilization(currentMetrics, storageOpts, googleCloudClient)
+	if err != nil {
+		s.log.Error(err, "failed to get storage utilization")
+		s.setMetricsUnavailable(autoscaler, metricFailureReasons[bigtablev2.StorageMetricSourceType], err)
+
+		return
+	}
+
+	currentNodes, err := googleCloudClient.GetCurrentNodeCount(autoscaler.Spec.BigtableClusterRef.ClusterID)
+	if err != nil {
+		s.log.Error(err, "failed to get nodes count")
+		s.setMetricsUnavailable(autoscaler, "FailedGetNodeCount", err)
+
+		return
+	}
+
+	for i := range currentMetrics {
+		current := &currentMetrics[i].Current
+		if current.Value != nil && currentNodes > 0 {
+			current.AverageValue = resource.NewMilliQuantity(current.Value.MilliValue()/int64(currentNodes), resource.DecimalSI)
+		}
+	}
+
+	if nodes_calculator.DetectManualOverride(&autoscaler.Status, &autoscaler.Spec, currentNodes, time.Now()) {
+		s.recorder.Eventf(autoscaler, corev1.EventTypeNormal, "ManualOverrideDetected",
+			"The number of nodes was changed from %d to %d outside of the autoscaler; scaling is held off for %s",
+			*autoscaler.Status.LastAppliedNodes, currentNodes, autoscaler.Spec.ManualOverrideGracePeriod.Duration)
+	}
+
+	autoscaler.Status.CurrentMetrics = currentMetrics
+	autoscaler.Status.CurrentStorageUtilization = &storageUtilization
+	autoscaler.Status.CurrentNodes = &currentNodes
+	autoscaler.Status.LastFetchTime = &metav1.Time{Time: time.Now()}
+	s.log.Info("Metric read", "metrics", currentMetrics, "node count", currentNodes, "autoscaler", autoscaler.ObjectMeta.Name)
+	setMetricsCondition(autoscaler, metav1.ConditionTrue, "MetricsFetched", "metrics and node count were fetched")
+}
+
+// currentStorageUtilization returns the storage utilization from the metrics already fetched,
+// and fetches it otherwise: it is always needed to know the minimum number of nodes.
+func currentStorageUtilization(currentMetrics []bigtablev2.MetricStatus, opts googlecloud.QueryOptions,
+	googleCloudClient googlecloud.GoogleCloudClient) (int32, error) {
+	for _, metric := range currentMetrics {
+		if metric.Type == bigtablev2.StorageMetricSourceType && metric.Current.AverageUtilization != nil {
+			return *metric.Current.AverageUtilization, nil
+		}
+	}
+
+	return googleCloudClient.GetCurrentStorageUtilization(opts)
+}
+
+// queryOptions returns how the metrics of the autoscaler are read, using the client defaults
+// for the window and alignment period that are not set.
+func queryOptions(spec *bigtablev2.BigtableAutoscalerSpec, aggregation bigtablev2.MetricAggregation) googlecloud.QueryOptions {
+	opts := googlecloud.QueryOptions{Aggregation: googlecloud.Aggregation(aggregation)}
+
+	if spec.MetricWindow != nil {
+		opts.Window = spec.MetricWindow.Duration
+	}
+
+	if spec.AlignmentPeriod != nil {
+		opts.AlignmentPeriod = spec.AlignmentPeriod.Duration
+	}
+
+	return opts
+}
+
+func fetchMetric(metric bigtablev2.MetricSpec, opts googlecloud.QueryOptions,
+	googleCloudClient googlecloud.GoogleCloudClient) (bigtablev2.MetricValueStatus, error) {
+	var utilization int32
+	var err error
+
+	switch metric.Type {
+	case bigtablev2.CPUMetricSourceType:
+		utilization, err = googleCloudClient.GetCurrentCPULoad(opts)
+	case bigtablev2.HottestNodeCPUMetricSourceType:
+		utilization, err = googleCloudClient.GetCurrentHottestNodeCPULoad(opts)
+	case bigtablev2.StorageMetricSourceType:
+		utilization, err = googleCloudClient.GetCurrentStorageUtilization(opts)
+	case bigtablev2.ExternalMetricSourceType:
+		if metric.External == nil {
+			return bigtablev2.MetricValueStatus{}, fmt.Errorf("external metric source is not set")
+		}
+
+		kind := metric.External.MetricKind
+		opts.Rate = kind == bigtablev2.DeltaMetricKind || kind == bigtablev2.CumulativeMetricKind
+
+		value, err := googleCloudClient.GetCurrentMetricValue(metric.External.MetricType, metric.External.Filter, opts)
+		if err != nil {
+			return bigtablev2.MetricValueStatus{}, err
+		}
+
+		quantity := resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+
+		return bigtablev2.MetricValueStatus{Value: quantity}, nil
+	default:
+		return bigtablev2.MetricValueStatus{}, fmt.Errorf("unsupported metric type %q", metric.Type)
+	}
+
+	if err != nil {
+		return bigtablev2.MetricValueStatus{}, err
+	}
+
+	return bigtablev2.MetricValueStatus{AverageUtilization: &utilization}, nil
+}
+
+// setMetricsUnavailable marks the metrics as unavailable, emitting a warning event only when
+// they become unavailable or fail for another reason, so that a failure isn't reported every tick.
+func (s *Syncer) setMetricsUnavailable(autoscaler *bigtablev2.BigtableAutoscaler, reason string, err error) {
+	previous := conditions.Find(autoscaler.Status.Conditions, bigtablev2.ConditionMetricsAvailable)
+	if previous == nil || previous.Status != metav1.ConditionFalse || previous.Reason != reason {
+		s.recorder.Eventf(autoscaler, corev1.EventTypeWarning, "MetricsUnavailable", "%s: %v", reason, err)
+	}
+
+	setMetricsCondition(autoscaler, metav1.ConditionFalse, reason, err.Error())
+}
+
+func setMetricsCondition(autoscaler *bigtablev2.BigtableAutoscaler, status metav1.ConditionStatus, reason, message string) {
+	conditions.Set(&autoscaler.Status.Conditions, bigtablev2.Condition{
+		Type:               bigtablev2.ConditionMetricsAvailable,
+		Status:             status,
+		ObservedGeneration: autoscaler.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -2,82 +2,290 @@ package status_test
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 
-	bigtablev1 "bigtable-autoscaler.com/m/v2/api/v1"
+	bigtablev2 "bigtable-autoscaler.com/m/v2/api/v2"
 	"bigtable-autoscaler.com/m/v2/mocks"
-	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"bigtable-autoscaler.com/m/v2/pkg/conditions"
 	"bigtable-autoscaler.com/m/v2/pkg/googlecloud"
+	"bigtable-autoscaler.com/m/v2/pkg/pointer"
 	"bigtable-autoscaler.com/m/v2/pkg/status"
 )
 
+// syncOnce registers the autoscaler and returns a copy of it taken when the sync routine first
+// writes its status, once the routine is stopped.
+func syncOnce(
+	autoscaler *bigtablev2.BigtableAutoscaler,
+	googleCloudClient googlecloud.GoogleCloudClient,
+	recorder record.EventRecorder,
+) *bigtablev2.BigtableAutoscaler {
+	var synced *bigtablev2.BigtableAutoscaler
+	written := make(chan struct{})
+	once := sync.Once{}
+
+	mockStatusWriter := mocks.Writer{}
+	mockStatusWriter.On("Update", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		once.Do(func() {
+			synced = args.Get(1).(*bigtablev2.BigtableAutoscaler).DeepCopy()
+			close(written)
+		})
+	})
+
+	s := status.NewSyncer(&mockStatusWriter, recorder, ctrl.Log.WithName("test runtime"))
+	s.Register(context.Background(), autoscaler, googleCloudClient)
+	<-written
+	s.Unregister(autoscaler.UID)
+
+	return synced
+}
+
 func TestRegister(t *testing.T) {
-	autoscaler := bigtablev1.BigtableAutoscaler{
-		Spec: bigtablev1.BigtableAutoscalerSpec{
-			BigtableClusterRef: bigtablev1.BigtableClusterRef{
+	autoscaler := bigtablev2.BigtableAutoscaler{
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			Metrics: []bigtablev2.MetricSpec{
+				{
+					Type: bigtablev2.CPUMetricSourceType,
+					Target: bigtablev2.MetricTarget{
+						Type:               bigtablev2.UtilizationMetricType,
+						AverageUtilization: pointer.Int32(50),
+					},
+				},
+			},
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
 				ClusterID: "cluster-id",
 			},
 		},
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	mockStatusWriter := mocks.Writer{}
-	mockStatusWriter.On("Update", mock.Anything, &autoscaler).Return(nil).Run(func(args mock.Arguments) {
-		wg.Done()
-	})
-
 	cpuUsage := int32(55)
 	nodesCount := int32(2)
 
 	mockGoogleCloudClient := mocks.GoogleCloudClient{}
-	mockGoogleCloudClient.On("GetCurrentCPULoad").Return(cpuUsage, nil)
+	mockGoogleCloudClient.On("GetCurrentCPULoad", mock.Anything).Return(cpuUsage, nil)
+	mockGoogleCloudClient.On("GetCurrentStorageUtilization", mock.Anything).Return(int32(30), nil)
 	mockGoogleCloudClient.On("GetCurrentNodeCount", "cluster-id").Return(nodesCount, nil)
 
-	type fields struct {
-		ctx               context.Context
-		writer            status.Writer
-		autoscaler        *bigtablev1.BigtableAutoscaler
-		googleCloudClient googlecloud.GoogleCloudClient
-		log               logr.Logger
+	synced := syncOnce(&autoscaler, &mockGoogleCloudClient, record.NewFakeRecorder(10))
+
+	if assert.Len(t, synced.Status.CurrentMetrics, 1) {
+		assert.Equal(t, bigtablev2.CPUMetricSourceType, synced.Status.CurrentMetrics[0].Type)
+		assert.Equal(t, int32(55), *synced.Status.CurrentMetrics[0].Current.AverageUtilization)
+	}
+	assert.Equal(t, int32(2), *synced.Status.CurrentNodes)
+	assert.Equal(t, int32(30), *synced.Status.CurrentStorageUtilization)
+	assert.NotNil(t, synced.Status.LastFetchTime)
+	assert.True(t, conditions.IsTrue(synced.Status.Conditions, bigtablev2.ConditionMetricsAvailable))
+}
+
+func TestRegisterExternalMetric(t *testing.T) {
+	autoscaler := bigtablev2.BigtableAutoscaler{
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			Metrics: []bigtablev2.MetricSpec{
+				{
+					Type: bigtablev2.ExternalMetricSourceType,
+					External: &bigtablev2.ExternalMetricSource{
+						MetricType: "serviceruntime.googleapis.com/api/request_count",
+						MetricKind: bigtablev2.DeltaMetricKind,
+					},
+					Target: bigtablev2.MetricTarget{
+						Type:  bigtablev2.ValueMetricType,
+						Value: resource.NewQuantity(100, resource.DecimalSI),
+					},
+				},
+			},
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
+				ClusterID: "cluster-id",
+			},
+		},
+	}
+
+	mockGoogleCloudClient := mocks.GoogleCloudClient{}
+	mockGoogleCloudClient.On("GetCurrentMetricValue", "serviceruntime.googleapis.com/api/request_count", "",
+		mock.MatchedBy(func(opts googlecloud.QueryOptions) bool { return opts.Rate })).Return(42.5, nil)
+	mockGoogleCloudClient.On("GetCurrentStorageUtilization", mock.Anything).Return(int32(30), nil)
+	mockGoogleCloudClient.On("GetCurrentNodeCount", "cluster-id").Return(int32(2), nil)
+
+	synced := syncOnce(&autoscaler, &mockGoogleCloudClient, record.NewFakeRecorder(10))
+
+	if assert.Len(t, synced.Status.CurrentMetrics, 1) {
+		assert.Equal(t, "42500m", synced.Status.CurrentMetrics[0].Current.Value.String())
 	}
-	tests := []struct {
-		name   string
-		fields fields
-	}{
-		{
-			name: "starts the syncer",
-			fields: fields{
-				ctx:               context.Background(),
-				writer:            &mockStatusWriter,
-				autoscaler:        &autoscaler,
-				googleCloudClient: &mockGoogleCloudClient,
-				log:               ctrl.Log.WithName("test runtime"),
+}
+
+func TestRegisterMetricsUnavailable(t *testing.T) {
+	autoscaler := bigtablev2.BigtableAutoscaler{
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			Metrics: []bigtablev2.MetricSpec{
+				{
+					Type: bigtablev2.CPUMetricSourceType,
+					Target: bigtablev2.MetricTarget{
+						Type:               bigtablev2.UtilizationMetricType,
+						AverageUtilization: pointer.Int32(50),
+					},
+				},
+			},
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
+				ClusterID: "cluster-id",
 			},
 		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := status.NewSyncer(
-				tt.fields.writer,
-				tt.fields.log,
-			)
-			s.Register(
-				tt.fields.ctx,
-				tt.fields.autoscaler,
-				tt.fields.googleCloudClient,
-			)
-			wg.Wait()
+
+	mockGoogleCloudClient := mocks.GoogleCloudClient{}
+	mockGoogleCloudClient.On("GetCurrentCPULoad", mock.Anything).Return(int32(-1), errors.New("failed to get metrics"))
+
+	recorder := record.NewFakeRecorder(10)
+	synced := syncOnce(&autoscaler, &mockGoogleCloudClient, recorder)
+
+	if assert.Len(t, recorder.Events, 1) {
+		assert.Equal(t, "Warning MetricsUnavailable FailedGetCPULoad: failed to get metrics", <-recorder.Events)
+	}
+
+	assert.Nil(t, synced.Status.CurrentMetrics)
+	assert.True(t, conditions.IsFalse(synced.Status.Conditions, bigtablev2.ConditionMetricsAvailable))
+
+	ready := conditions.Find(synced.Status.Conditions, bigtablev2.ConditionReady)
+	if assert.NotNil(t, ready) {
+		assert.Equal(t, "FailedGetCPULoad", ready.Reason)
+	}
+}
+
+func TestRegisterManualOverride(t *testing.T) {
+	autoscaler := bigtablev2.BigtableAutoscaler{
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			Metrics: []bigtablev2.MetricSpec{
+				{
+					Type: bigtablev2.CPUMetricSourceType,
+					Target: bigtablev2.MetricTarget{
+						Type:               bigtablev2.UtilizationMetricType,
+						AverageUtilization: pointer.Int32(50),
+					},
+				},
+			},
+			ManualOverrideGracePeriod: &metav1.Duration{Duration: 30 * time.Minute},
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
+				ClusterID: "cluster-id",
+			},
+		},
+		Status: bigtablev2.BigtableAutoscalerStatus{
+			LastAppliedNodes: pointer.Int32(2),
+		},
+	}
+
+	mockGoogleCloudClient := mocks.GoogleCloudClient{}
+	mockGoogleCloudClient.On("GetCurrentCPULoad", mock.Anything).Return(int32(55), nil)
+	mockGoogleCloudClient.On("GetCurrentStorageUtilization", mock.Anything).Return(int32(30), nil)
+	mockGoogleCloudClient.On("GetCurrentNodeCount", "cluster-id").Return(int32(6), nil)
+
+	recorder := record.NewFakeRecorder(10)
+	synced := syncOnce(&autoscaler, &mockGoogleCloudClient, recorder)
+
+	if assert.Len(t, recorder.Events, 1) {
+		assert.Equal(t, "Normal ManualOverrideDetected The number of nodes was changed from 2 to 6 outside of the autoscaler; "+
+			"scaling is held off for 30m0s", <-recorder.Events)
+	}
+
+	assert.Equal(t, int32(2), *synced.Status.LastAppliedNodes)
+	if assert.NotNil(t, synced.Status.ManualOverride) {
+		assert.Equal(t, int32(6), synced.Status.ManualOverride.Nodes)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	autoscaler := bigtablev2.BigtableAutoscaler{}
+	autoscaler.UID = "autoscaler-uid"
+
+	s := status.NewSyncer(&mocks.Writer{}, record.NewFakeRecorder(10), ctrl.Log.WithName("test runtime"))
+	s.Register(context.Background(), &autoscaler, &mocks.GoogleCloudClient{})
+
+	assert.True(t, s.Unregister(autoscaler.UID))
+	assert.False(t, s.Unregister(autoscaler.UID))
+}
+
+func TestUnregisterWaitsForRoutine(t *testing.T) {
+	autoscaler := bigtablev2.BigtableAutoscaler{}
+	autoscaler.UID = "autoscaler-uid"
+
+	mockGoogleCloudClient := mocks.GoogleCloudClient{}
+	mockGoogleCloudClient.On("GetCurrentStorageUtilization", mock.Anything).Return(int32(30), nil)
+	mockGoogleCloudClient.On("GetCurrentNodeCount", mock.Anything).Return(int32(2), nil)
+
+	writing := make(chan struct{})
+	release := make(chan struct{})
+	once := sync.Once{}
+
+	mockStatusWriter := mocks.Writer{}
+	mockStatusWriter.On("Update", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		once.Do(func() {
+			close(writing)
+			<-release
 		})
+	})
+
+	s := status.NewSyncer(&mockStatusWriter, record.NewFakeRecorder(10), ctrl.Log.WithName("test runtime"))
+	s.Register(context.Background(), &autoscaler, &mockGoogleCloudClient)
+	<-writing
+
+	unregistered := make(chan struct{})
+	go func() {
+		s.Unregister(autoscaler.UID)
+		close(unregistered)
+	}()
+
+	select {
+	case <-unregistered:
+		t.Fatal("Unregister returned while the routine was still running")
+	case <-time.After(100 * time.Millisecond):
 	}
-	if assert.NotNil(t, autoscaler) {
-		assert.Equal(t, int32(55), *autoscaler.Status.CurrentCPUUtilization)
-		assert.Equal(t, int32(2), *autoscaler.Status.CurrentNodes)
+
+	close(release)
+	<-unregistered
+}
+
+func TestRegisterCopiesAutoscaler(t *testing.T) {
+	autoscaler := bigtablev2.BigtableAutoscaler{
+		Spec: bigtablev2.BigtableAutoscalerSpec{
+			BigtableClusterRef: bigtablev2.BigtableClusterRef{
+				ClusterID: "cluster-id",
+			},
+		},
 	}
 
+	mockGoogleCloudClient := mocks.GoogleCloudClient{}
+	mockGoogleCloudClient.On("GetCurrentStorageUtilization", mock.Anything).Return(int32(30), nil)
+	mockGoogleCloudClient.On("GetCurrentNodeCount", "cluster-id").Return(int32(2), nil)
+
+	var synced *bigtablev2.BigtableAutoscaler
+	written := make(chan struct{})
+	once := sync.Once{}
+
+	mockStatusWriter := mocks.Writer{}
+	mockStatusWriter.On("Update", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		once.Do(func() {
+			synced = args.Get(1).(*bigtablev2.BigtableAutoscaler).DeepCopy()
+			close(written)
+		})
+	})
+
+	s := status.NewSyncer(&mockStatusWriter, record.NewFakeRecorder(10), ctrl.Log.WithName("test runtime"))
+	s.Register(context.Background(), &autoscaler, &mockGoogleCloudClient)
+
+	// The caller keeps changing its autoscaler, as the reconciler does, while the routine runs.
+	autoscaler.Spec.BigtableClusterRef.ClusterID = "other-cluster-id"
+	autoscaler.Status.CurrentNodes = pointer.Int32(4)
+
+	<-written
+	s.Unregister(autoscaler.UID)
+
+	assert.Equal(t, int32(2), *synced.Status.CurrentNodes)
+	assert.Equal(t, int32(4), *autoscaler.Status.CurrentNodes)
 }